@@ -0,0 +1,189 @@
+package shegerpay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrBatchDeadlineExceeded marks BatchVerify items left unfinished when
+// the batch deadline passed. Errors carrying it also match
+// context.DeadlineExceeded.
+var ErrBatchDeadlineExceeded = errors.New("batch deadline exceeded")
+
+// defaultBatchConcurrency is the number of concurrent verifications used
+// when BatchVerifyParams.Concurrency is not set
+const defaultBatchConcurrency = 4
+
+// BatchVerifyParams contains parameters for BatchVerify
+type BatchVerifyParams struct {
+	Items []VerifyParams
+
+	// Concurrency is the number of verifications in flight at once.
+	// Defaults to 4; tune it to your rate-limit tier.
+	Concurrency int
+
+	// Timeout bounds the whole batch, retries and rate-limit pauses
+	// included. When it passes, in-flight verifications are cancelled and
+	// BatchVerify returns. 0 means no limit beyond ctx's own deadline.
+	Timeout time.Duration
+}
+
+// BatchVerifyResult is the outcome of one item in a batch
+type BatchVerifyResult struct {
+	Index  int // position in BatchVerifyParams.Items
+	Params VerifyParams
+	Result *VerificationResult
+	Err    error
+
+	// Completed is true when the item ran to an outcome, successful or
+	// not, rather than being skipped or interrupted by cancellation or
+	// the batch deadline
+	Completed bool
+}
+
+// BatchVerify verifies many transactions with a bounded worker pool and
+// returns one result per item, in input order. When the API rate-limits a
+// call, all workers pause together before the item is retried.
+//
+// If params.Timeout or ctx's deadline passes first, the results are
+// partial: unfinished items have Completed false and an Err matching
+// ErrBatchDeadlineExceeded.
+func (c *Client) BatchVerify(ctx context.Context, params BatchVerifyParams) []BatchVerifyResult {
+	concurrency := params.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultBatchConcurrency
+	}
+	if params.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, params.Timeout)
+		defer cancel()
+	}
+
+	results := make([]BatchVerifyResult, len(params.Items))
+	for i, item := range params.Items {
+		results[i] = BatchVerifyResult{Index: i, Params: item}
+	}
+
+	gate := &rateGate{}
+	started := runPool(ctx, len(params.Items), concurrency, func(i int) {
+		result, err := c.batchVerifyOne(ctx, gate, params.Items[i])
+		results[i].Result, results[i].Err = result, err
+		results[i].Completed = !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	})
+	for i := started; i < len(results); i++ {
+		results[i].Err = ctx.Err()
+	}
+
+	if ctx.Err() == context.DeadlineExceeded {
+		for i := range results {
+			if !results[i].Completed {
+				results[i].Err = fmt.Errorf("%w: %w", ErrBatchDeadlineExceeded, results[i].Err)
+			}
+		}
+	}
+	return results
+}
+
+// ConversionResult is the outcome of one conversion in a BatchConvert
+type ConversionResult struct {
+	Index      int // position in the requests slice
+	Params     ConvertCurrencyParams
+	Conversion *Conversion // includes the applied rate
+	Err        error
+}
+
+// BatchConvert submits several wallet conversions concurrently and
+// returns one result per request, in input order. Conversions are not
+// atomic: each leg succeeds or fails on its own.
+func (c *Client) BatchConvert(ctx context.Context, requests []ConvertCurrencyParams) []ConversionResult {
+	results := make([]ConversionResult, len(requests))
+	for i, params := range requests {
+		results[i] = ConversionResult{Index: i, Params: params}
+	}
+
+	started := runPool(ctx, len(requests), defaultBatchConcurrency, func(i int) {
+		results[i].Conversion, results[i].Err = c.ConvertCurrencyWithParams(ctx, requests[i])
+	})
+	for i := started; i < len(results); i++ {
+		results[i].Err = ctx.Err()
+	}
+	return results
+}
+
+// runPool calls fn for each index in [0, n) on up to concurrency
+// goroutines. It stops handing out work once ctx is done and returns how
+// many items were started; items are started in index order.
+func runPool(ctx context.Context, n, concurrency int, fn func(i int)) int {
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				fn(i)
+			}
+		}()
+	}
+
+	started := 0
+feed:
+	for i := 0; i < n; i++ {
+		select {
+		case jobs <- i:
+			started++
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return started
+}
+
+// batchVerifyOne verifies a single batch item, waiting out global
+// rate-limit pauses
+func (c *Client) batchVerifyOne(ctx context.Context, gate *rateGate, params VerifyParams) (*VerificationResult, error) {
+	for attempt := 0; ; attempt++ {
+		if err := gate.wait(ctx); err != nil {
+			return nil, err
+		}
+
+		result, err := c.VerifyContext(ctx, params)
+		if wait, retry := c.rateLimitDelay(c.policy(http.MethodPost, "/api/v1/verify"), attempt, err); retry {
+			gate.pause(wait)
+			continue
+		}
+		return result, err
+	}
+}
+
+// rateGate pauses every worker of a batch after a rate-limited response
+type rateGate struct {
+	mu    sync.Mutex
+	until time.Time
+}
+
+// wait blocks until the current pause, if any, has elapsed
+func (g *rateGate) wait(ctx context.Context) error {
+	g.mu.Lock()
+	d := time.Until(g.until)
+	g.mu.Unlock()
+	if d <= 0 {
+		return nil
+	}
+	return sleepContext(ctx, d)
+}
+
+// pause extends the pause to at least d from now
+func (g *rateGate) pause(d time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if until := time.Now().Add(d); until.After(g.until) {
+		g.until = until
+	}
+}
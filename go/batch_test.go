@@ -0,0 +1,59 @@
+package shegerpay
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// slowTransactionID is answered only after the client gives up
+const slowTransactionID = "FTSLOW"
+
+func batchTestHandler(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	if r.PostForm.Get("transaction_id") == slowTransactionID {
+		<-r.Context().Done()
+		return
+	}
+	writeJSON(w, http.StatusBadRequest, `{"detail":"unknown transaction"}`)
+}
+
+func TestBatchVerifyCompleted(t *testing.T) {
+	client := newTestClient(t, batchTestHandler)
+
+	results := client.BatchVerify(context.Background(), BatchVerifyParams{
+		Items: []VerifyParams{
+			{Provider: ProviderCBE, TransactionID: "FT1", Amount: 10},
+			{Provider: ProviderCBE, TransactionID: slowTransactionID, Amount: 10},
+		},
+		Timeout: 100 * time.Millisecond,
+	})
+
+	var apiErr *APIError
+	if !results[0].Completed || !errors.As(results[0].Err, &apiErr) {
+		t.Errorf("failed item = %+v; want completed with its APIError", results[0])
+	}
+	if results[1].Completed || !errors.Is(results[1].Err, ErrBatchDeadlineExceeded) {
+		t.Errorf("interrupted item = %+v; want incomplete, ErrBatchDeadlineExceeded", results[1])
+	}
+}
+
+func TestVerifyCSVCompleted(t *testing.T) {
+	client := newTestClient(t, batchTestHandler)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	input := "transaction_id,amount,provider\nFT1,10,cbe\n" + slowTransactionID + ",10,cbe\nFT3,oops,cbe\n"
+	results, err := client.VerifyCSV(ctx, strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []bool{true, false, true} {
+		if results[i].Completed != want {
+			t.Errorf("row %d Completed = %v, want %v (err %v)", i, results[i].Completed, want, results[i].Err)
+		}
+	}
+}
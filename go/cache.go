@@ -0,0 +1,150 @@
+package shegerpay
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultCacheSize is the number of entries kept by NewMemoryCache when
+// no positive size is given
+const defaultCacheSize = 256
+
+// Cache stores raw GET response bodies. Implementations must be safe for
+// concurrent use; a shared store such as Redis lets several processes
+// reuse each other's responses.
+type Cache interface {
+	// Get returns the body stored under key, if present and not expired
+	Get(key string) ([]byte, bool)
+
+	// Set stores body under key for ttl
+	Set(key string, body []byte, ttl time.Duration)
+}
+
+// WithCache caches successful GET responses in cache for ttl, e.g.
+// GetSupportedProviders or GetWalletBalance on hot read paths. A ttl of 0
+// caches nothing by default, so only endpoints given a TTL with
+// WithCacheTTL are cached. Entries are scoped to the API key, base URL
+// and WithOnBehalfOf tenant; clients using WithBearerToken bypass the
+// cache, since a token does not identify the account.
+func WithCache(cache Cache, ttl time.Duration) ClientOption {
+	return func(c *Client) {
+		c.cache = cache
+		c.cacheTTL = ttl
+	}
+}
+
+// WithCacheTTL overrides the cache TTL for one endpoint path, e.g.
+// "/api/v1/providers". The query string is ignored when matching, but
+// responses for different queries are cached separately. A ttl of 0
+// disables caching for the path. It has no effect without WithCache.
+func WithCacheTTL(path string, ttl time.Duration) ClientOption {
+	return func(c *Client) {
+		if c.cacheTTLs == nil {
+			c.cacheTTLs = map[string]time.Duration{}
+		}
+		c.cacheTTLs[path] = ttl
+	}
+}
+
+// cacheEntry returns the cache key and TTL for a request, or a zero TTL
+// if the response must not be cached
+func (c *Client) cacheEntry(method, path string) (string, time.Duration) {
+	if c.cache == nil || method != http.MethodGet {
+		return "", 0
+	}
+
+	endpoint, _, _ := strings.Cut(path, "?")
+	ttl, ok := c.cacheTTLs[endpoint]
+	if !ok {
+		ttl = c.cacheTTL
+	}
+	if ttl <= 0 {
+		return "", 0
+	}
+
+	scope, ok := c.accountScope()
+	if !ok {
+		return "", 0
+	}
+	return scope + " " + path, ttl
+}
+
+// accountScope identifies the account, environment and tenant requests
+// are made for, without exposing the API key, so that responses cached or
+// remembered for one are never served to another. Clients authenticating
+// with WithBearerToken have no stable identity and report false.
+func (c *Client) accountScope() (string, bool) {
+	if c.tokenSource != nil {
+		return "", false
+	}
+	sum := sha256.Sum256([]byte(c.apiKey.Load().(string) + "\x00" + c.baseURL + "\x00" + c.onBehalfOf))
+	return hex.EncodeToString(sum[:8]), true
+}
+
+// memoryCache is an in-process LRU Cache
+type memoryCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	order      *list.List // front is most recently used
+	entries    map[string]*list.Element
+}
+
+type memoryCacheEntry struct {
+	key     string
+	body    []byte
+	expires time.Time
+}
+
+// NewMemoryCache returns an in-memory Cache that evicts the least
+// recently used entry once it holds maxEntries (256 if maxEntries <= 0)
+func NewMemoryCache(maxEntries int) Cache {
+	if maxEntries <= 0 {
+		maxEntries = defaultCacheSize
+	}
+	return &memoryCache{
+		maxEntries: maxEntries,
+		order:      list.New(),
+		entries:    map[string]*list.Element{},
+	}
+}
+
+func (m *memoryCache) Get(key string) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	elem, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*memoryCacheEntry)
+	if time.Now().After(entry.expires) {
+		m.order.Remove(elem)
+		delete(m.entries, key)
+		return nil, false
+	}
+	m.order.MoveToFront(elem)
+	return entry.body, true
+}
+
+func (m *memoryCache) Set(key string, body []byte, ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry := &memoryCacheEntry{key: key, body: body, expires: time.Now().Add(ttl)}
+	if elem, ok := m.entries[key]; ok {
+		elem.Value = entry
+		m.order.MoveToFront(elem)
+		return
+	}
+	m.entries[key] = m.order.PushFront(entry)
+	if m.order.Len() > m.maxEntries {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.entries, oldest.Value.(*memoryCacheEntry).key)
+	}
+}
@@ -0,0 +1,42 @@
+package shegerpay
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCacheHitHonoursCloneError(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, `[]`)
+	}, WithCache(NewMemoryCache(0), time.Minute))
+
+	if _, err := client.ListWebhooks(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	broken := client.Clone(WithEnvironment("nowhere"))
+	if _, err := broken.ListWebhooks(context.Background()); err == nil {
+		t.Error("clone with an invalid option was served from the cache")
+	}
+}
+
+func TestCacheBypassedForBearerTokens(t *testing.T) {
+	var calls atomic.Int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		writeJSON(w, http.StatusOK, `[]`)
+	}, WithCache(NewMemoryCache(0), time.Minute), WithBearerToken(TokenSourceFunc(func(context.Context) (string, error) {
+		return "token", nil
+	})))
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.ListWebhooks(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("made %d requests, want 2", got)
+	}
+}
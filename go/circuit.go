@@ -0,0 +1,117 @@
+package shegerpay
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without contacting the API while the circuit
+// breaker installed by WithCircuitBreaker is open
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitState is the state of the client's circuit breaker
+type CircuitState int
+
+const (
+	CircuitClosed   CircuitState = iota // requests flow normally
+	CircuitOpen                         // requests fail fast with ErrCircuitOpen
+	CircuitHalfOpen                     // a single probe request is allowed
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "closed"
+}
+
+// WithCircuitBreaker fails requests fast with ErrCircuitOpen after
+// failureThreshold consecutive failures (transport errors and 5xx
+// responses), so an outage does not tie up a goroutine per call for the
+// full timeout. Once cooldown has elapsed a single probe request is let
+// through: success closes the circuit, failure opens it again. Each retry
+// attempt counts separately.
+func WithCircuitBreaker(failureThreshold int, cooldown time.Duration) ClientOption {
+	return func(c *Client) {
+		c.breaker = &circuitBreaker{threshold: failureThreshold, cooldown: cooldown}
+	}
+}
+
+// CircuitState reports the circuit breaker's current state, e.g. for
+// metrics. It is always CircuitClosed without WithCircuitBreaker.
+func (c *Client) CircuitState() CircuitState {
+	if c.breaker == nil {
+		return CircuitClosed
+	}
+	return c.breaker.state()
+}
+
+// circuitBreaker tracks consecutive failures across all requests of a
+// client and its clones
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time // zero while closed
+	probing  bool      // a half-open probe is in flight
+}
+
+func (b *circuitBreaker) state() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch {
+	case b.openedAt.IsZero():
+		return CircuitClosed
+	case time.Since(b.openedAt) < b.cooldown:
+		return CircuitOpen
+	}
+	return CircuitHalfOpen
+}
+
+// allow reports ErrCircuitOpen unless a request may be sent now. After
+// the cooldown only one caller is admitted, as the probe.
+func (b *circuitBreaker) allow() (probe bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.openedAt.IsZero() {
+		return false, nil
+	}
+	if b.probing || time.Since(b.openedAt) < b.cooldown {
+		return false, ErrCircuitOpen
+	}
+	b.probing = true
+	return true, nil
+}
+
+// record updates the breaker with the outcome of a request admitted by
+// allow. Any response below 500 counts as a success since the API
+// answered; requests abandoned by the caller are not counted at all.
+func (b *circuitBreaker) record(ctx context.Context, probe bool, resp *response, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if probe {
+		b.probing = false
+	}
+
+	failed := (resp == nil && err != nil) || (resp != nil && resp.statusCode >= 500)
+	if failed && ctx.Err() != nil {
+		return
+	}
+	if !failed {
+		b.failures = 0
+		b.openedAt = time.Time{}
+		return
+	}
+
+	b.failures++
+	if probe || b.failures >= b.threshold {
+		b.openedAt = time.Now()
+	}
+}
@@ -0,0 +1,88 @@
+package shegerpay
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+)
+
+// maxETagEntries bounds the responses remembered by
+// WithConditionalRequests; an arbitrary entry is dropped when full
+const maxETagEntries = 1024
+
+// WithConditionalRequests remembers the ETag and body of GET responses
+// and revalidates them with If-None-Match on the next identical call, e.g.
+// when polling GetWalletBalance. If the server answers 304 Not Modified,
+// the remembered body is decoded instead, saving the transfer. Responses
+// are remembered per API key, base URL and WithOnBehalfOf tenant; clients
+// using WithBearerToken make plain requests.
+func WithConditionalRequests() ClientOption {
+	return func(c *Client) {
+		c.etags = &etagStore{entries: map[string]etagEntry{}}
+	}
+}
+
+// etagStore holds the last ETag-tagged response per request. A nil
+// *etagStore disables conditional requests.
+type etagStore struct {
+	mu      sync.Mutex
+	entries map[string]etagEntry
+}
+
+type etagEntry struct {
+	etag string
+	body []byte
+}
+
+// conditional returns the store key for a request, and a context carrying
+// If-None-Match when a response for it is remembered. The key is empty
+// if the request is not eligible.
+func (c *Client) conditional(ctx context.Context, method, path string) (context.Context, string) {
+	if c.etags == nil || method != http.MethodGet {
+		return ctx, ""
+	}
+	scope, ok := c.accountScope()
+	if !ok {
+		return ctx, ""
+	}
+	key := scope + " " + path
+
+	c.etags.mu.Lock()
+	entry, ok := c.etags.entries[key]
+	c.etags.mu.Unlock()
+	if ok {
+		ctx = withRequestHeader(ctx, "If-None-Match", entry.etag)
+	}
+	return ctx, key
+}
+
+// revalidate decodes the remembered body into result after a 304, and
+// remembers resp if it carries an ETag
+func (c *Client) revalidate(key string, resp *response, result interface{}) error {
+	c.etags.mu.Lock()
+	defer c.etags.mu.Unlock()
+
+	if resp.statusCode == http.StatusNotModified {
+		entry, ok := c.etags.entries[key]
+		if !ok {
+			return errors.New("304 Not Modified for a response no longer remembered")
+		}
+		resp.body = entry.body
+		return c.decodeResponse("", entry.body, result)
+	}
+
+	etag := resp.header.Get("ETag")
+	if etag == "" || len(resp.body) == 0 {
+		delete(c.etags.entries, key)
+		return nil
+	}
+	if _, ok := c.etags.entries[key]; !ok && len(c.etags.entries) >= maxETagEntries {
+		for evict := range c.etags.entries {
+			delete(c.etags.entries, evict)
+			break
+		}
+	}
+	c.etags.entries[key] = etagEntry{etag: etag, body: resp.body}
+	return nil
+}
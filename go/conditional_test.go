@@ -0,0 +1,47 @@
+package shegerpay
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestConditionalRequestsScoped(t *testing.T) {
+	var conditional []string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		conditional = append(conditional, r.Header.Get("If-None-Match"))
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		writeJSON(w, http.StatusOK, `[]`)
+	}, WithConditionalRequests())
+	ctx := context.Background()
+
+	tenantA := client.Clone(WithOnBehalfOf("A"))
+	for _, c := range []*Client{tenantA, tenantA, client.Clone(WithOnBehalfOf("B"))} {
+		if _, err := c.ListWebhooks(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	bearer := client.Clone(WithBearerToken(TokenSourceFunc(func(context.Context) (string, error) {
+		return "token", nil
+	})))
+	for i := 0; i < 2; i++ {
+		if _, err := bearer.ListWebhooks(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []string{"", `"v1"`, "", "", ""}
+	if len(conditional) != len(want) {
+		t.Fatalf("If-None-Match per request = %q, want %q", conditional, want)
+	}
+	for i := range want {
+		if conditional[i] != want[i] {
+			t.Errorf("If-None-Match per request = %q, want %q", conditional, want)
+			break
+		}
+	}
+}
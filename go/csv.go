@@ -0,0 +1,105 @@
+package shegerpay
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// VerifyCSV verifies every row of a settlement CSV. The first row must be
+// a header naming the transaction_id and amount columns and, optionally,
+// a provider column; other columns are ignored. Rows are verified with
+// BatchVerify and one result is returned per data row, in input order,
+// with Index counting data rows from 0. A malformed row gets a completed
+// result whose Err names its line and is not sent to the API.
+//
+// The error is non-nil only if the header is missing or invalid or the
+// reader fails.
+func (c *Client) VerifyCSV(ctx context.Context, r io.Reader) ([]BatchVerifyResult, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("csv: missing header row")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("csv: read header: %w", err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"transaction_id", "amount"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("csv: header has no %q column", name)
+		}
+	}
+
+	var results []BatchVerifyResult
+	var items []VerifyParams
+	var rows []int // index into results for each item
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			results = append(results, BatchVerifyResult{Index: len(results), Err: err, Completed: true})
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("csv: %w", err)
+		}
+
+		params, err := parseCSVRow(record, columns)
+		if err != nil {
+			line, _ := reader.FieldPos(0)
+			err = fmt.Errorf("csv line %d: %w", line, err)
+		} else {
+			items = append(items, params)
+			rows = append(rows, len(results))
+		}
+		results = append(results, BatchVerifyResult{Index: len(results), Params: params, Err: err, Completed: err != nil})
+	}
+
+	for i, verified := range c.BatchVerify(ctx, BatchVerifyParams{Items: items}) {
+		results[rows[i]].Result = verified.Result
+		results[rows[i]].Err = verified.Err
+		results[rows[i]].Completed = verified.Completed
+	}
+	return results, nil
+}
+
+// parseCSVRow builds VerifyParams from a record using the header's
+// column positions
+func parseCSVRow(record []string, columns map[string]int) (VerifyParams, error) {
+	field := func(name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	params := VerifyParams{
+		TransactionID: field("transaction_id"),
+		Provider:      Provider(field("provider")),
+	}
+	if params.TransactionID == "" {
+		return params, newValidationError("transaction_id", "transaction_id is required")
+	}
+
+	amount, err := strconv.ParseFloat(field("amount"), 64)
+	if err != nil || amount <= 0 {
+		return params, newValidationError("amount", fmt.Sprintf("invalid amount %q", field("amount")))
+	}
+	params.Amount = amount
+	return params, nil
+}
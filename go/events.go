@@ -0,0 +1,204 @@
+package shegerpay
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// eventStreamPath is the server-sent events endpoint used by StreamEvents
+const eventStreamPath = "/api/v1/events/stream"
+
+// StreamEvents subscribes to live events, such as "payment.verified" or
+// "dispute.created", over server-sent events. Pass no eventTypes to
+// receive every event. The initial connection is made before returning,
+// so bad credentials are reported as an error.
+//
+// When the stream drops it is reopened with the client's Backoff,
+// resuming after the last event received. The channel is closed when ctx
+// is cancelled or the server rejects a reconnection with a 4xx status
+// other than 429; reconnection failures are reported to WithSlog and
+// WithRequestHook.
+//
+// Usage:
+//
+//	events, err := client.StreamEvents(ctx, []string{"payment.verified"})
+//	if err != nil {
+//	    // handle error
+//	}
+//	for event := range events {
+//	    fmt.Println(event.Type, event.ID)
+//	}
+func (c *Client) StreamEvents(ctx context.Context, eventTypes []string) (<-chan WebhookEvent, error) {
+	path := eventStreamPath
+	if len(eventTypes) > 0 {
+		path += "?" + url.Values{"types": {strings.Join(eventTypes, ",")}}.Encode()
+	}
+
+	// The stream stays open indefinitely, so the overall client timeout
+	// must not apply
+	streamClient := *c.http
+	streamClient.Timeout = 0
+	s := &eventStream{client: c, http: &streamClient, path: path}
+
+	body, err := s.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan WebhookEvent)
+	go s.run(ctx, body, events)
+	return events, nil
+}
+
+// eventStream is one StreamEvents subscription
+type eventStream struct {
+	client      *Client
+	http        *http.Client
+	path        string
+	lastEventID string
+	retry       time.Duration // server-requested reconnection delay
+}
+
+// connect opens the stream, resuming after lastEventID
+func (s *eventStream) connect(ctx context.Context) (io.ReadCloser, error) {
+	req, err := s.client.newRequest(ctx, http.MethodGet, s.path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+	if s.lastEventID != "" {
+		req.Header.Set("Last-Event-ID", s.lastEventID)
+	}
+
+	start := time.Now()
+	resp, err := s.http.Do(req)
+	info := RequestInfo{Method: http.MethodGet, Path: s.path, Duration: time.Since(start), Err: err}
+	if resp != nil {
+		info.StatusCode = resp.StatusCode
+		info.RequestID = resp.Header.Get("X-Request-ID")
+	}
+	if err != nil {
+		s.client.observe(ctx, info)
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		info.Body = body
+		s.client.observe(ctx, info)
+		return nil, newAPIError(resp, body)
+	}
+	s.client.observe(ctx, info)
+	return resp.Body, nil
+}
+
+// run delivers events from body, reconnecting until ctx is done or the
+// server refuses the subscription
+func (s *eventStream) run(ctx context.Context, body io.ReadCloser, events chan<- WebhookEvent) {
+	defer close(events)
+	for attempt := 0; ; {
+		if body != nil {
+			if s.read(ctx, body, events) {
+				attempt = 0
+			}
+			body.Close()
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		wait := s.retry
+		if wait <= 0 {
+			wait = s.client.backoff(attempt)
+		}
+		attempt++
+		if sleepContext(ctx, wait) != nil {
+			return
+		}
+
+		var err error
+		body, err = s.connect(ctx)
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode < 500 && apiErr.StatusCode != http.StatusTooManyRequests {
+			return
+		}
+	}
+}
+
+// read parses server-sent events from body until it ends or ctx is done;
+// the request is bound to ctx, so cancellation also unblocks the scanner.
+// It reports whether any event was received.
+func (s *eventStream) read(ctx context.Context, body io.Reader, events chan<- WebhookEvent) bool {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxWebhookBodySize)
+
+	received := false
+	var id, eventType string
+	var data strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if data.Len() > 0 {
+				event, ok := parseStreamEvent(id, eventType, data.String())
+				if ok {
+					select {
+					case events <- event:
+						received = true
+					case <-ctx.Done():
+						return received
+					}
+				}
+			}
+			if id != "" {
+				s.lastEventID = id
+			}
+			id, eventType = "", ""
+			data.Reset()
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "id":
+			id = value
+		case "event":
+			eventType = value
+		case "data":
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil && ms >= 0 {
+				s.retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+	return received
+}
+
+// parseStreamEvent decodes one server-sent event's data as a WebhookEvent,
+// filling the ID and type from the SSE fields when the payload omits them
+func parseStreamEvent(id, eventType, data string) (WebhookEvent, bool) {
+	var event WebhookEvent
+	if err := json.Unmarshal([]byte(data), &event); err != nil {
+		return event, false
+	}
+	if event.ID == "" {
+		event.ID = id
+	}
+	if event.Type == "" {
+		event.Type = eventType
+	}
+	return event, event.Type != ""
+}
@@ -0,0 +1,115 @@
+package shegerpay
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Export formats
+const (
+	ExportFormatNDJSON = "ndjson"
+	ExportFormatCSV    = "csv"
+)
+
+// exportPageSize is the history page size used while exporting
+const exportPageSize = 100
+
+// csvHeader lists the columns written by a CSV export
+var csvHeader = []string{"id", "transaction_id", "provider", "status", "valid", "amount", "currency", "mode", "created_at"}
+
+// ExportOptions controls a resumable history export
+type ExportOptions struct {
+	Format string // ExportFormatNDJSON or ExportFormatCSV
+
+	// Cursor resumes an export from a checkpoint of an earlier run
+	Cursor string
+
+	// OnCheckpoint, if set, is called each time a page has been fully
+	// written and flushed, with the cursor to resume from
+	OnCheckpoint func(cursor string)
+}
+
+// ExportHistory streams the full transaction history to w as NDJSON or CSV,
+// fetching one page at a time so memory use stays flat for large accounts
+func (c *Client) ExportHistory(ctx context.Context, w io.Writer, format string) error {
+	_, err := c.ExportHistoryFrom(ctx, w, ExportOptions{Format: format})
+	return err
+}
+
+// ExportHistoryFrom streams history like ExportHistory, starting at
+// opts.Cursor. It returns the last checkpoint: on failure, pass it as
+// Cursor to resume without losing or repeating pages; on success it is
+// empty. The CSV header is only written when starting from the beginning,
+// so resumed output can be appended to the earlier file.
+func (c *Client) ExportHistoryFrom(ctx context.Context, w io.Writer, opts ExportOptions) (string, error) {
+	write, flush, err := newExportWriter(w, opts.Format, opts.Cursor == "")
+	if err != nil {
+		return opts.Cursor, err
+	}
+
+	checkpoint := opts.Cursor
+	it := c.HistoryIterator(ctx, HistoryFilter{Limit: exportPageSize, Cursor: opts.Cursor})
+	for it.Next() {
+		if err := write(it.Item()); err != nil {
+			return checkpoint, err
+		}
+		if it.atPageEnd() {
+			if err := flush(); err != nil {
+				return checkpoint, err
+			}
+			checkpoint = it.cursor
+			if opts.OnCheckpoint != nil && checkpoint != "" {
+				opts.OnCheckpoint(checkpoint)
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return checkpoint, err
+	}
+	if err := it.Err(); err != nil {
+		return checkpoint, err
+	}
+	return "", nil
+}
+
+// newExportWriter returns functions that write one transaction in the
+// given format and flush any buffered output. header controls whether a
+// CSV header is written first.
+func newExportWriter(w io.Writer, format string, header bool) (write func(Transaction) error, flush func() error, err error) {
+	switch format {
+	case ExportFormatNDJSON:
+		encoder := json.NewEncoder(w)
+		return func(tx Transaction) error { return encoder.Encode(tx) }, func() error { return nil }, nil
+
+	case ExportFormatCSV:
+		writer := csv.NewWriter(w)
+		if header {
+			if err := writer.Write(csvHeader); err != nil {
+				return nil, nil, err
+			}
+		}
+		write = func(tx Transaction) error {
+			return writer.Write([]string{
+				tx.ID,
+				tx.TransactionID,
+				tx.Provider,
+				tx.Status,
+				strconv.FormatBool(tx.Valid),
+				strconv.FormatFloat(tx.Amount, 'f', -1, 64),
+				tx.Currency,
+				tx.Mode,
+				tx.CreatedAt,
+			})
+		}
+		flush = func() error {
+			writer.Flush()
+			return writer.Error()
+		}
+		return write, flush, nil
+	}
+	return nil, nil, newValidationError("format", fmt.Sprintf("unsupported export format %q", format))
+}
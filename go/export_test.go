@@ -0,0 +1,34 @@
+package shegerpay
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestExportCSVHeaderOnlyOnFirstRun(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("cursor") == "" {
+			writeJSON(w, http.StatusOK, `{"data":[{"id":"1","transaction_id":"FT1","provider":"cbe","status":"verified","valid":true,"amount":10}],"next_cursor":"c2","has_more":true}`)
+			return
+		}
+		writeJSON(w, http.StatusOK, `{"data":[{"id":"2","transaction_id":"FT2","provider":"cbe","status":"verified","valid":true,"amount":20}],"has_more":false}`)
+	})
+
+	var first strings.Builder
+	if _, err := client.ExportHistoryFrom(context.Background(), &first, ExportOptions{Format: ExportFormatCSV}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(first.String(), "id,transaction_id,") {
+		t.Errorf("fresh export = %q, want a header", first.String())
+	}
+
+	var resumed strings.Builder
+	if _, err := client.ExportHistoryFrom(context.Background(), &resumed, ExportOptions{Format: ExportFormatCSV, Cursor: "c2"}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "2,FT2,cbe,verified,true,20,,,\n"; resumed.String() != want {
+		t.Errorf("resumed export = %q, want %q", resumed.String(), want)
+	}
+}
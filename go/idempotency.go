@@ -0,0 +1,44 @@
+package shegerpay
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// IdempotencyKeyHeader is the request header the server uses to detect
+// repeated submissions of the same operation
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// NewIdempotencyKey returns a random key for operations that move money,
+// such as ConvertCurrencyParams.IdempotencyKey. Generate it once per
+// logical operation and store it with your own record, so a retry after a
+// timeout or crash reuses it and the server executes the operation once.
+func NewIdempotencyKey() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic("shegerpay: reading random bytes: " + err.Error())
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// requestHeadersKey is the context key for per-request headers
+type requestHeadersKey struct{}
+
+// withRequestHeader returns a context whose requests carry an extra
+// header, for headers that vary per call rather than per client
+func withRequestHeader(ctx context.Context, key, value string) context.Context {
+	headers := requestHeaders(ctx).Clone()
+	if headers == nil {
+		headers = http.Header{}
+	}
+	headers.Set(key, value)
+	return context.WithValue(ctx, requestHeadersKey{}, headers)
+}
+
+// requestHeaders returns the per-request headers attached to ctx
+func requestHeaders(ctx context.Context) http.Header {
+	headers, _ := ctx.Value(requestHeadersKey{}).(http.Header)
+	return headers
+}
@@ -0,0 +1,109 @@
+package shegerpay
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// maxRateLimitRetries bounds how often a rate-limited call is retried by
+// the iterators and batch helpers
+const maxRateLimitRetries = 5
+
+// Page is one page of a cursor-paginated list endpoint
+type Page[T any] struct {
+	Data       []T    `json:"data"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
+
+// Iterator walks a paginated list endpoint, fetching pages lazily.
+//
+// Usage:
+//
+//	it := client.HistoryIterator(ctx, shegerpay.HistoryFilter{})
+//	for it.Next() {
+//	    tx := it.Item()
+//	}
+//	if err := it.Err(); err != nil {
+//	    // handle error
+//	}
+type Iterator[T any] struct {
+	ctx    context.Context
+	client *Client
+	fetch  func(ctx context.Context, cursor string) (*Page[T], error)
+	cursor string
+	items  []T
+	index  int
+	item   T
+	done   bool
+	err    error
+}
+
+func newIterator[T any](ctx context.Context, client *Client, cursor string, fetch func(ctx context.Context, cursor string) (*Page[T], error)) *Iterator[T] {
+	return &Iterator[T]{ctx: ctx, client: client, fetch: fetch, cursor: cursor}
+}
+
+// Next advances to the next item, fetching the next page when needed.
+// It returns false when the list is exhausted or an error occurred.
+func (it *Iterator[T]) Next() bool {
+	for it.index >= len(it.items) {
+		if it.done || it.err != nil {
+			return false
+		}
+		page, err := it.fetchPage()
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.items, it.index = page.Data, 0
+		it.cursor = page.NextCursor
+		it.done = !page.HasMore || page.NextCursor == ""
+	}
+	it.item = it.items[it.index]
+	it.index++
+	return true
+}
+
+// atPageEnd reports whether the current item is the last of its page, so
+// that resuming from it.cursor would not skip or repeat items
+func (it *Iterator[T]) atPageEnd() bool {
+	return it.index == len(it.items)
+}
+
+// Item returns the current item
+func (it *Iterator[T]) Item() T {
+	return it.item
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+// fetchPage fetches the page at the current cursor, backing off and
+// retrying when the API rate-limits the request (see rateLimitDelay)
+func (it *Iterator[T]) fetchPage() (*Page[T], error) {
+	for attempt := 0; ; attempt++ {
+		page, err := it.fetch(it.ctx, it.cursor)
+		wait, retry := it.client.rateLimitDelay(it.client.policy(http.MethodGet, ""), attempt, err)
+		if !retry {
+			return page, err
+		}
+		if err := sleepContext(it.ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// sleepContext waits for d or until ctx is done, whichever comes first
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
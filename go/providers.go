@@ -0,0 +1,90 @@
+package shegerpay
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// providerFailureTTL is how long a failed capabilities fetch is remembered,
+// so that an outage does not add a request to every verification
+const providerFailureTTL = 30 * time.Second
+
+// ProviderInfo describes a payment provider supported by the API
+type ProviderInfo struct {
+	ID           Provider `json:"id"`
+	Name         string   `json:"name"`
+	SubProviders []string `json:"sub_providers,omitempty"`
+}
+
+// providerCache holds the capabilities list for the client's lifetime
+type providerCache struct {
+	mu        sync.Mutex
+	providers []ProviderInfo
+	err       error // last fetch failure, reused until providerFailureTTL
+	failedAt  time.Time
+}
+
+// GetSupportedProviders gets the providers and their valid sub-providers
+// from the capabilities endpoint
+func (c *Client) GetSupportedProviders(ctx context.Context) ([]ProviderInfo, error) {
+	var result []ProviderInfo
+	err := c.requestContext(ctx, "GET", "/api/v1/providers", nil, &result)
+	return result, err
+}
+
+// cachedProviders returns the capabilities list, fetching it on first use.
+// The lock is not held during the fetch, so concurrent first callers may
+// each fetch it.
+func (c *Client) cachedProviders(ctx context.Context) ([]ProviderInfo, error) {
+	cache := c.providers
+	cache.mu.Lock()
+	providers, err, failedAt := cache.providers, cache.err, cache.failedAt
+	cache.mu.Unlock()
+	if providers != nil {
+		return providers, nil
+	}
+	if err != nil && time.Since(failedAt) < providerFailureTTL {
+		return nil, err
+	}
+
+	providers, err = c.GetSupportedProviders(ctx)
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if err != nil {
+		// A caller giving up says nothing about the endpoint
+		if ctx.Err() == nil {
+			cache.err, cache.failedAt = err, time.Now()
+		}
+		return nil, err
+	}
+	cache.providers, cache.err = providers, nil
+	return providers, nil
+}
+
+// validateSubProvider checks subProvider against the capabilities of
+// provider. If the capabilities cannot be fetched, or the provider is not
+// listed, validation is left to the server.
+func (c *Client) validateSubProvider(ctx context.Context, provider Provider, subProvider string) error {
+	providers, err := c.cachedProviders(ctx)
+	if err != nil {
+		return nil
+	}
+
+	for _, info := range providers {
+		if info.ID.normalize() != provider {
+			continue
+		}
+		for _, valid := range info.SubProviders {
+			if strings.EqualFold(valid, subProvider) {
+				return nil
+			}
+		}
+		return newValidationError("SubProvider", fmt.Sprintf("sub-provider %q is not supported by %s; valid: %s",
+			subProvider, provider, strings.Join(info.SubProviders, ", ")))
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package shegerpay
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestProviderFetchFailureCached(t *testing.T) {
+	var fetches atomic.Int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/providers" {
+			fetches.Add(1)
+			writeJSON(w, http.StatusInternalServerError, `{"detail":"unavailable"}`)
+			return
+		}
+		writeJSON(w, http.StatusOK, `{"valid":true,"status":"verified"}`)
+	})
+
+	params := VerifyParams{Provider: ProviderCBE, TransactionID: "FT123", Amount: 100, SubProvider: "mobile"}
+	for i := 0; i < 3; i++ {
+		if _, err := client.VerifyContext(context.Background(), params); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := fetches.Load(); got != 1 {
+		t.Errorf("capabilities fetched %d times, want 1", got)
+	}
+}
+
+func TestCloneForOtherAccountFetchesOwnCapabilities(t *testing.T) {
+	var fetches atomic.Int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/providers" {
+			fetches.Add(1)
+			writeJSON(w, http.StatusOK, `[{"id":"cbe","name":"CBE","sub_providers":["mobile"]}]`)
+			return
+		}
+		writeJSON(w, http.StatusOK, `{"valid":true,"status":"verified"}`)
+	})
+
+	params := VerifyParams{Provider: ProviderCBE, TransactionID: "FT123", Amount: 100, SubProvider: "mobile"}
+	for _, c := range []*Client{client, client.Clone(WithTimeout(time.Minute)), client.Clone(WithOnBehalfOf("B"))} {
+		if _, err := c.VerifyContext(context.Background(), params); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := fetches.Load(); got != 2 {
+		t.Errorf("capabilities fetched %d times, want 2 (shared by the plain clone)", got)
+	}
+	if client.Clone(WithBaseURL("https://example.com")).webhookKeys == client.webhookKeys {
+		t.Error("clone for another host shares the webhook key cache")
+	}
+}
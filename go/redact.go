@@ -0,0 +1,49 @@
+package shegerpay
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Patterns of credentials that must never reach errors or logs. A bearer
+// token must be at least 16 characters long, so ordinary prose such as
+// "invalid bearer token" is left alone.
+var (
+	apiKeyPattern      = regexp.MustCompile(`\b(sk_(?:test|live)_)[A-Za-z0-9_-]+`)
+	bearerTokenPattern = regexp.MustCompile(`(?i)\b(bearer\s+)[A-Za-z0-9._~+/=-]{16,}`)
+)
+
+// redact scrubs API keys and bearer tokens from s, keeping the key prefix
+// so the mode is still visible, e.g. "sk_live_[REDACTED]". Every string
+// derived from a request or response passes through it before it is put
+// in an error or a log entry.
+func redact(s string) string {
+	s = apiKeyPattern.ReplaceAllString(s, "${1}[REDACTED]")
+	return bearerTokenPattern.ReplaceAllString(s, "${1}[REDACTED]")
+}
+
+// redactedError wraps an error from outside the SDK, such as a
+// TokenSource, redacting its message while keeping it matchable with
+// errors.Is and errors.As
+type redactedError struct {
+	err error
+}
+
+func (e redactedError) Error() string {
+	return redact(e.err.Error())
+}
+
+func (e redactedError) Unwrap() error {
+	return e.err
+}
+
+// String describes the client without its credentials, so printing it
+// with %v or %+v is safe
+func (c *Client) String() string {
+	return fmt.Sprintf("shegerpay.Client{baseURL: %q, mode: %q, apiKey: %q}", c.baseURL, c.mode, redact(c.apiKey.Load().(string)))
+}
+
+// GoString makes %#v as safe as String
+func (c *Client) GoString() string {
+	return c.String()
+}
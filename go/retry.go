@@ -0,0 +1,250 @@
+package shegerpay
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	retryBaseDelay = 500 * time.Millisecond
+	retryMaxDelay  = 30 * time.Second
+)
+
+// Backoff returns how long to wait before the retry following the given
+// 0-based attempt
+type Backoff func(attempt int) time.Duration
+
+// WithRetry retries rate-limited (429) and transient server errors
+// (500, 502, 503, 504) up to maxRetries times, waiting per the configured
+// Backoff (full-jitter exponential by default, see WithBackoff). A
+// Retry-After header, in seconds or as an HTTP-date, takes precedence; a
+// date already past retries immediately. Transient network errors
+// (timeouts, connection failures, temporary DNS errors) are retried for
+// reads. Writes are retried after a 5xx or network error only when they
+// carry an idempotency key (see WithWritePolicy). Retries stop early when
+// the request context is cancelled or its deadline would be exceeded. The
+// last error is wrapped, so e.g. errors.Is(err, ErrServiceUnavailable)
+// holds once retries on a 503 are exhausted.
+func WithRetry(maxRetries int) ClientOption {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+	}
+}
+
+// WithRetryBudget caps retries across all concurrent calls of the client
+// and its clones, so a struggling backend is not hit by a retry storm.
+// Every call earns ratio retry tokens (e.g. 0.1 allows retries for about
+// 10% of request volume) and every retry spends one; at most burst
+// tokens are held, and the budget starts full. Once it is spent, failed
+// calls return their error without retrying. The budget also covers the
+// rate-limit retries of iterators, StreamHistory and BatchVerify.
+func WithRetryBudget(ratio float64, burst int) ClientOption {
+	return func(c *Client) {
+		c.retryBudget = &retryBudget{ratio: ratio, max: float64(burst), tokens: float64(burst)}
+	}
+}
+
+// retryBudget is the token bucket installed by WithRetryBudget. A nil
+// *retryBudget allows every retry.
+type retryBudget struct {
+	ratio float64
+	max   float64
+
+	mu     sync.Mutex
+	tokens float64
+}
+
+// deposit credits the budget for a new call
+func (b *retryBudget) deposit() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = min(b.tokens+b.ratio, b.max)
+}
+
+// withdraw spends a token for a retry, reporting false if none is left
+func (b *retryBudget) withdraw() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// WithBackoff sets the delay curve between retries
+func WithBackoff(backoff Backoff) ClientOption {
+	return func(c *Client) {
+		c.backoff = backoff
+	}
+}
+
+// ConstantBackoff waits the same delay before every retry
+func ConstantBackoff(delay time.Duration) Backoff {
+	return func(int) time.Duration {
+		return delay
+	}
+}
+
+// ExponentialBackoff doubles the delay from base on each attempt, up to max
+func ExponentialBackoff(base, max time.Duration) Backoff {
+	return func(attempt int) time.Duration {
+		delay := base << attempt
+		if delay <= 0 || delay > max {
+			return max
+		}
+		return delay
+	}
+}
+
+// FullJitterBackoff waits a random delay between zero and the exponential
+// delay for the attempt, so many clients hitting the same 429 window do
+// not retry in lockstep
+func FullJitterBackoff(base, max time.Duration) Backoff {
+	exponential := ExponentialBackoff(base, max)
+	return func(attempt int) time.Duration {
+		return time.Duration(rand.Int63n(int64(exponential(attempt)) + 1))
+	}
+}
+
+// RequestPolicy sets the timeout and retry count for a category of
+// requests, see WithReadPolicy and WithWritePolicy
+type RequestPolicy struct {
+	// Timeout bounds each attempt, including reading the response.
+	// 0 uses the client's WithTimeout.
+	Timeout time.Duration
+
+	// MaxRetries replaces WithRetry for the category; 0 disables retries
+	MaxRetries int
+}
+
+// WithReadPolicy applies policy to reads: GET requests and verification
+// lookups, which are safe to retry
+func WithReadPolicy(policy RequestPolicy) ClientOption {
+	return func(c *Client) {
+		c.readPolicy = &policy
+	}
+}
+
+// WithWritePolicy applies policy to writes such as CreateRefund and
+// ConvertCurrency. Whatever the policy, a write is retried after a 5xx or
+// network error only if it carries an idempotency key, since the server
+// may already have executed it; 429 responses are always retried.
+func WithWritePolicy(policy RequestPolicy) ClientOption {
+	return func(c *Client) {
+		c.writePolicy = &policy
+	}
+}
+
+// isWrite reports whether a request may change state. Verification
+// (including quick verification) and bulk status endpoints are lookups
+// even though they are POSTed.
+func isWrite(method, path string) bool {
+	if method == http.MethodGet {
+		return false
+	}
+	switch {
+	case strings.HasPrefix(path, "/api/v1/verify"),
+		path == "/api/v1/quick-verify",
+		path == "/api/v1/transactions/statuses":
+		return false
+	}
+	return true
+}
+
+// policy returns the effective policy for a request
+func (c *Client) policy(method, path string) RequestPolicy {
+	policy := c.readPolicy
+	if isWrite(method, path) {
+		policy = c.writePolicy
+	}
+	if policy == nil {
+		return RequestPolicy{Timeout: c.http.Timeout, MaxRetries: c.maxRetries}
+	}
+	if policy.Timeout <= 0 {
+		return RequestPolicy{Timeout: c.http.Timeout, MaxRetries: policy.MaxRetries}
+	}
+	return *policy
+}
+
+// shouldRetry reports whether a failed attempt should be retried and how
+// long to wait first
+func (c *Client) shouldRetry(ctx context.Context, method, path string, attempt int, err error) (time.Duration, bool) {
+	if err == nil || attempt >= c.policy(method, path).MaxRetries || ctx.Err() != nil {
+		return 0, false
+	}
+
+	// A repeated write is only harmless if the server can deduplicate it
+	safe := !isWrite(method, path) || requestHeaders(ctx).Get(IdempotencyKeyHeader) != ""
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		if safe && isTransientNetworkError(err) {
+			return c.backoff(attempt), true
+		}
+		return 0, false
+	}
+	switch apiErr.StatusCode {
+	case http.StatusTooManyRequests:
+	case http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		if !safe {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+
+	if apiErr.hasRetryAfter {
+		return apiErr.RetryAfter, true
+	}
+	return c.backoff(attempt), true
+}
+
+// isTransientNetworkError reports whether a transport error is likely to
+// succeed on retry. Caller cancellation is ruled out by shouldRetry.
+func isTransientNetworkError(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTimeout || dnsErr.IsTemporary
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr)
+}
+
+// rateLimitDelay reports whether a helper looping over API calls (the
+// iterators, StreamHistory and BatchVerify) should retry a call that
+// failed with err on its 0-based attempt, and how long to wait first.
+// Only 429 responses are retried, after Retry-After or the client's
+// Backoff, and each retry is drawn from the retry budget. policy is the
+// one the call was sent with: if it retries, send has already handled the
+// 429, so the retries are not stacked.
+func (c *Client) rateLimitDelay(policy RequestPolicy, attempt int, err error) (time.Duration, bool) {
+	var apiErr *APIError
+	if policy.MaxRetries > 0 || attempt >= maxRateLimitRetries || !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+	if !c.retryBudget.withdraw() {
+		return 0, false
+	}
+	if apiErr.hasRetryAfter {
+		return apiErr.RetryAfter, true
+	}
+	return c.backoff(attempt), true
+}
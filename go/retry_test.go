@@ -0,0 +1,95 @@
+package shegerpay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryStopsWhenContextEndsMidBackoff(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusServiceUnavailable, `{"detail":"try later"}`)
+	}, WithRetry(3), WithBackoff(func(int) time.Duration { return 10 * time.Second }))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := client.ListWebhooks(ctx)
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("returned after %v, want promptly after cancellation", elapsed)
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want it to wrap context.Canceled", err)
+	}
+	if !errors.Is(err, ErrServiceUnavailable) {
+		t.Errorf("got %v, want it to keep the last API error", err)
+	}
+}
+
+func TestRetryStopsBeforeSleepingPastDeadline(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusServiceUnavailable, `{"detail":"try later"}`)
+	}, WithRetry(3), WithBackoff(func(int) time.Duration { return 10 * time.Second }))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.ListWebhooks(ctx)
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("returned after %v, want without waiting for the deadline", elapsed)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want it to wrap context.DeadlineExceeded", err)
+	}
+}
+
+func TestQuickVerifyRetriedAsRead(t *testing.T) {
+	var calls atomic.Int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		writeJSON(w, http.StatusServiceUnavailable, `{"detail":"try later"}`)
+	}, WithRetry(2), WithBackoff(func(int) time.Duration { return 0 }))
+
+	_, err := client.QuickVerifyContext(context.Background(), "FT123", 100)
+	if !errors.Is(err, ErrServiceUnavailable) {
+		t.Fatalf("got %v, want ErrServiceUnavailable", err)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("made %d attempts, want 3", got)
+	}
+}
+
+func TestAttemptsDoNotContainTheError(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusServiceUnavailable, `{"detail":"try later"}`)
+	}, WithRetry(2), WithBackoff(func(int) time.Duration { return 0 }))
+
+	_, err := client.ListWebhooks(context.Background())
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("got %v, want *APIError", err)
+	}
+	if len(apiErr.Attempts) != 3 || apiErr.RetryCount != 2 {
+		t.Fatalf("Attempts = %+v, RetryCount = %d; want 3 attempts", apiErr.Attempts, apiErr.RetryCount)
+	}
+	for i, attempt := range apiErr.Attempts {
+		if attempt.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("attempt %d status = %d, want 503", i, attempt.StatusCode)
+		}
+		var inner *APIError
+		if errors.As(attempt.Err, &inner) && inner == apiErr {
+			t.Errorf("attempt %d refers back to the error itself", i)
+		}
+	}
+	if apiErr.Attempts[2].Err != nil {
+		t.Errorf("last attempt Err = %v, want nil", apiErr.Attempts[2].Err)
+	}
+	// Would recurse forever if the error contained itself
+	_ = fmt.Sprintf("%+v", apiErr)
+}
@@ -12,16 +12,25 @@ package shegerpay
 
 import (
 	"bytes"
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
+	"crypto/tls"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"maps"
+	"mime"
+	"net"
 	"net/http"
 	"net/url"
+	"slices"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -30,12 +39,140 @@ const (
 	DefaultBaseURL = "https://api.shegerpay.com"
 )
 
+// EnvProduction is the only environment accepted by WithEnvironment.
+// There is no separate sandbox host: test mode is selected by using an
+// sk_test_ key.
+const EnvProduction = "production"
+
+// environmentURLs maps each environment to its API base URL
+var environmentURLs = map[string]string{
+	EnvProduction: DefaultBaseURL,
+}
+
 // Errors
 var (
 	ErrInvalidAPIKey = errors.New("invalid API key format")
 	ErrMissingAPIKey = errors.New("API key is required")
+	ErrModeMismatch  = errors.New("API key mode (test/live) does not match the client")
+
+	// ErrConflict matches API errors with status 409, e.g. approving a
+	// refund that was already approved or rejected
+	ErrConflict = errors.New("resource was already processed")
+
+	// ErrServiceUnavailable matches API errors with status 503, e.g.
+	// during scheduled maintenance. The *APIError's RetryAfter says how
+	// long the outage is expected to last, if the server said so.
+	ErrServiceUnavailable = errors.New("service unavailable")
+
+	// ErrUnexpectedContentType is returned when a successful response is
+	// not JSON, e.g. an HTML page injected by a proxy
+	ErrUnexpectedContentType = errors.New("unexpected response content type")
 )
 
+// ValidationError is returned when parameters fail client-side validation,
+// before any request is sent. Use errors.As to tell it apart from APIError.
+type ValidationError struct {
+	Field   string // parameter name, e.g. "TransactionID"
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+func newValidationError(field, message string) *ValidationError {
+	return &ValidationError{Field: field, Message: message}
+}
+
+// APIError is returned when the API responds with a non-2xx status
+type APIError struct {
+	StatusCode int
+	Message    string
+
+	// RetryAfter is the wait requested by the server, if any. It is 0
+	// both when no Retry-After header was sent and when it named a time
+	// already past; hasRetryAfter tells the two apart.
+	RetryAfter    time.Duration
+	hasRetryAfter bool
+
+	// Duration is how long the failed call took
+	Duration time.Duration
+
+	// RetryCount is the number of retries made before giving up, and
+	// Attempts describes every attempt in order, the last one included.
+	// The last attempt's Err is nil: that error is the APIError itself.
+	RetryCount int
+	Attempts   []AttemptInfo
+}
+
+// AttemptInfo describes one attempt of an API call
+type AttemptInfo struct {
+	StatusCode int // 0 if no response was received
+	Duration   time.Duration
+	Err        error
+}
+
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+// Is reports whether the error matches a status sentinel such as ErrConflict
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrServiceUnavailable:
+		return e.StatusCode == http.StatusServiceUnavailable
+	}
+	return false
+}
+
+func newAPIError(resp *http.Response, body []byte) *APIError {
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+	apiErr.RetryAfter, apiErr.hasRetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	
+	if resp.StatusCode == 401 {
+		apiErr.Message = "invalid API key"
+		return apiErr
+	}
+	
+	var errResp struct {
+		Detail string `json:"detail"`
+	}
+	if json.Unmarshal(body, &errResp) == nil && errResp.Detail != "" {
+		apiErr.Message = redact(errResp.Detail)
+	} else {
+		apiErr.Message = http.StatusText(resp.StatusCode)
+	}
+	return apiErr
+}
+
+// parseRetryAfter parses a Retry-After header given either in seconds or
+// as an HTTP-date relative to now. A date in the past yields 0. The bool
+// is false if the header is absent or malformed.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	if wait := date.Sub(now); wait > 0 {
+		return wait, true
+	}
+	return 0, true
+}
+
 // VerificationResult represents the result of a payment verification
 type VerificationResult struct {
 	Valid         bool    `json:"valid"`
@@ -43,61 +180,404 @@ type VerificationResult struct {
 	Provider      string  `json:"provider,omitempty"`
 	TransactionID string  `json:"transaction_id,omitempty"`
 	Amount        float64 `json:"amount,omitempty"`
+	Currency      string  `json:"currency,omitempty"` // as reported by the provider
 	Reason        string  `json:"reason,omitempty"`
 	Mode          string  `json:"mode,omitempty"`
+
+	// ActualAmount is the amount the provider reported, which may differ
+	// from Amount when a tolerance was applied
+	ActualAmount float64 `json:"actual_amount,omitempty"`
+
+	// MatchedAmount is the candidate amount that verified, set by VerifyAny
+	MatchedAmount float64 `json:"-"`
+
+	// Metadata echoes back the custom fields sent with the verification
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// Receipt is the provider's raw receipt, present when requested with
+	// VerifyParams.IncludeReceipt
+	Receipt map[string]interface{} `json:"receipt,omitempty"`
+
+	// StatusCode is the HTTP status of the response. A 202 means the
+	// verification was accepted but is still pending.
+	StatusCode int `json:"-"`
+
+	// Duration is how long the API call took
+	Duration time.Duration `json:"-"`
+
+	// Replayed is true when the server returned a cached response for a
+	// repeated idempotent request instead of processing it again
+	Replayed bool `json:"-"`
+
+	// Cached is true when the result was served from the client's
+	// WithVerifyCache without calling the API
+	Cached bool `json:"-"`
+
+	// RawJSON is the undecoded response body, giving access to fields the
+	// SDK does not model yet
+	RawJSON json.RawMessage `json:"-"`
+}
+
+// setResponse records metadata of the HTTP exchange on the result
+func (r *VerificationResult) setResponse(resp *response) {
+	if resp == nil {
+		return
+	}
+	r.StatusCode = resp.statusCode
+	r.Duration = resp.duration
+	r.Replayed = resp.replayed()
+	r.RawJSON = resp.body
+}
+
+// Verification statuses
+const (
+	StatusVerified = "verified"
+	StatusPending  = "pending"
+	StatusFailed   = "failed"
+	StatusError    = "error"
+)
+
+// ExactAmount returns Amount exactly as the server sent it, e.g.
+// "9007199254740993", which float64 cannot represent. It is empty if the
+// response had no amount.
+func (r *VerificationResult) ExactAmount() json.Number {
+	return rawNumber(r.RawJSON, "amount")
+}
+
+// ExactActualAmount returns ActualAmount exactly as the server sent it
+func (r *VerificationResult) ExactActualAmount() json.Number {
+	return rawNumber(r.RawJSON, "actual_amount")
+}
+
+// IsFinal reports whether the verification has reached a terminal status
+// (verified or failed) and no further polling is needed. A 202 Accepted
+// response is never final, and neither is an unknown or missing status.
+func (r *VerificationResult) IsFinal() bool {
+	if r.StatusCode == http.StatusAccepted {
+		return false
+	}
+	return r.Status == StatusVerified || r.Status == StatusFailed
+}
+
+// Provider identifies a payment provider. Values are case-insensitive;
+// the SDK normalizes them to the lowercase form the API expects.
+type Provider string
+
+// Supported providers
+const (
+	ProviderCBE          Provider = "cbe"
+	ProviderTelebirr     Provider = "telebirr"
+	ProviderAwash        Provider = "awash"
+	ProviderBOA          Provider = "boa"
+	ProviderEbirrKaafi   Provider = "ebirr_kaafi"
+	ProviderEbirrCoop    Provider = "ebirr_coop"
+	ProviderBankTransfer Provider = "bank_transfer"
+	
+	// ProviderAuto disables the SDK's client-side provider guess in
+	// Verify and lets the server detect the provider
+	ProviderAuto Provider = "auto"
+)
+
+func (p Provider) normalize() Provider {
+	return Provider(strings.ToLower(strings.TrimSpace(string(p))))
 }
 
 // VerifyParams contains parameters for verification
 type VerifyParams struct {
-	Provider      string
+	// Provider has type Provider, so a provider name held in a string
+	// variable must be converted, e.g. Provider: shegerpay.Provider(name).
+	// Untyped constants such as "cbe" need no conversion.
+	Provider      Provider
 	TransactionID string
 	Amount        float64
 	MerchantName  string
-	SubProvider   string
+	SubProvider   string // validated against GetSupportedProviders
+
+	// Currency is the ISO 4217 code Amount is expected in, e.g. "ETB", for
+	// providers that settle in several currencies. Empty leaves it to the
+	// provider's default.
+	Currency string
+
+	// OrderReference matches the payment on your own order number, for
+	// providers that support it, when TransactionID is not known. With
+	// no TransactionID the provider is not auto-detected: the server
+	// decides unless Provider is set.
+	OrderReference string
+
+	// AmountTolerance accepts a provider-reported amount within this
+	// absolute difference of Amount, e.g. 0.01 for rounding
+	AmountTolerance float64
+
+	// AmountTolerancePercent accepts a provider-reported amount within this
+	// percentage of Amount, e.g. 1.5 for a fee of up to 1.5%
+	AmountTolerancePercent float64
+
+	// Metadata attaches your own references (order ID, customer ID, ...)
+	// to the verification. It is stored with the transaction and echoed
+	// back in results, history and webhooks.
+	Metadata map[string]string
+
+	// IncludeReceipt requests the provider's original receipt in
+	// VerificationResult.Receipt, e.g. to retain evidence for disputes
+	IncludeReceipt bool
 }
 
-// Client is the ShegerPay API client
-type Client struct {
-	apiKey  string
-	baseURL string
-	mode    string
-	http    *http.Client
+// Transaction is a single entry in the transaction history
+type Transaction struct {
+	ID            string            `json:"id"`
+	TransactionID string            `json:"transaction_id"`
+	Provider      string            `json:"provider"`
+	Status        string            `json:"status"`
+	Valid         bool              `json:"valid"`
+	Amount        float64           `json:"amount"`
+	Currency      string            `json:"currency,omitempty"`
+	Mode          string            `json:"mode,omitempty"`
+	Metadata      map[string]string `json:"metadata,omitempty"`
+	CreatedAt     string            `json:"created_at,omitempty"`
 }
 
-// NewClient creates a new ShegerPay client
-func NewClient(apiKey string, opts ...ClientOption) (*Client, error) {
-	if apiKey == "" {
-		return nil, ErrMissingAPIKey
+// HistoryPage is one page of transaction history
+type HistoryPage = Page[Transaction]
+
+// HistoryFilter narrows and pages through transaction history
+type HistoryFilter struct {
+	Provider Provider
+	Status   string
+	Limit    int
+
+	// From and To bound the transaction creation time; zero means unbounded
+	From time.Time
+	To   time.Time
+
+	// Cursor resumes a paged listing; ignored by GetHistoryFiltered
+	Cursor string
+}
+
+func (f HistoryFilter) values() url.Values {
+	query := url.Values{}
+	if f.Provider != "" {
+		query.Set("provider", string(f.Provider.normalize()))
 	}
-	
-	if !strings.HasPrefix(apiKey, "sk_test_") && !strings.HasPrefix(apiKey, "sk_live_") {
-		return nil, ErrInvalidAPIKey
+	if f.Status != "" {
+		query.Set("status", f.Status)
 	}
-	
-	mode := "live"
-	if strings.HasPrefix(apiKey, "sk_test_") {
-		mode = "test"
+	if f.Limit > 0 {
+		query.Set("limit", strconv.Itoa(f.Limit))
 	}
-	
+	if !f.From.IsZero() {
+		query.Set("from", f.From.UTC().Format(time.RFC3339))
+	}
+	if !f.To.IsZero() {
+		query.Set("to", f.To.UTC().Format(time.RFC3339))
+	}
+	if f.Cursor != "" {
+		query.Set("cursor", f.Cursor)
+	}
+	return query
+}
+
+// Client is the ShegerPay API client
+type Client struct {
+	apiKey      *atomic.Value // string; swapped by SetAPIKey, shared with clones
+	tokenSource TokenSource   // replaces apiKey when set
+	baseURL     string
+	mode        string
+	http        *http.Client
+	logger      *slog.Logger
+	hook        func(RequestInfo)
+
+	maxRetries  int
+	backoff     Backoff
+	retryBudget *retryBudget
+	readPolicy  *RequestPolicy
+	writePolicy *RequestPolicy
+	breaker     *circuitBreaker
+
+	verifyCache *verifyCache
+
+	defaultMerchantName string
+	locale              string
+	onBehalfOf          string
+	fallbackProvider    Provider
+	headers             http.Header
+	signingSecret       string
+	strictDecoding      bool
+	useNumber           bool
+	cache               Cache
+	etags               *etagStore
+	cacheTTL            time.Duration
+	cacheTTLs           map[string]time.Duration
+	providers           *providerCache
+	webhookKeys         *webhookKeyCache
+
+	// sharedTransport is set on clones; the transport is copied before
+	// an option modifies it
+	sharedTransport bool
+
+	// err records an invalid option, reported by NewClient or, for
+	// clones, by every request
+	err error
+}
+
+// NewClient creates a new ShegerPay client. apiKey may be empty when
+// WithBearerToken supplies the credentials instead.
+func NewClient(apiKey string, opts ...ClientOption) (*Client, error) {
 	client := &Client{
-		apiKey:  apiKey,
-		baseURL: DefaultBaseURL,
-		mode:    mode,
+		apiKey:              new(atomic.Value),
+		baseURL:             DefaultBaseURL,
+		defaultMerchantName: "ShegerPay Verification",
+		providers:           &providerCache{},
+		webhookKeys:         &webhookKeyCache{},
+		backoff:             FullJitterBackoff(retryBaseDelay, retryMaxDelay),
 		http: &http.Client{
 			Timeout: 30 * time.Second,
 		},
 	}
 	
+	client.apiKey.Store(apiKey)
+	
 	for _, opt := range opts {
 		opt(client)
 	}
+	if client.err != nil {
+		return nil, client.err
+	}
+	
+	if client.tokenSource == nil {
+		mode, err := keyMode(apiKey)
+		if err != nil {
+			return nil, err
+		}
+		client.mode = mode
+	}
 	
 	return client, nil
 }
 
+// Clone returns a copy of the client with opts applied on top of its
+// configuration, e.g. a longer WithTimeout for exports. The clone shares
+// the API key, caches and connection pool with c unless an option
+// changes the transport (WithProxy, WithTLSConfig, ...), in which case
+// it gets its own. A clone with a different WithBaseURL or WithOnBehalfOf
+// fetches its own provider capabilities and webhook keys. If an option is
+// invalid, every request made with the clone returns that error.
+func (c *Client) Clone(opts ...ClientOption) *Client {
+	httpClient := *c.http
+	clone := &Client{
+		apiKey:              c.apiKey,
+		tokenSource:         c.tokenSource,
+		baseURL:             c.baseURL,
+		mode:                c.mode,
+		http:                &httpClient,
+		logger:              c.logger,
+		hook:                c.hook,
+		maxRetries:          c.maxRetries,
+		backoff:             c.backoff,
+		retryBudget:         c.retryBudget,
+		readPolicy:          c.readPolicy,
+		writePolicy:         c.writePolicy,
+		breaker:             c.breaker,
+		verifyCache:         c.verifyCache,
+		defaultMerchantName: c.defaultMerchantName,
+		locale:              c.locale,
+		onBehalfOf:          c.onBehalfOf,
+		fallbackProvider:    c.fallbackProvider,
+		headers:             c.headers.Clone(),
+		signingSecret:       c.signingSecret,
+		strictDecoding:      c.strictDecoding,
+		useNumber:           c.useNumber,
+		cache:               c.cache,
+		etags:               c.etags,
+		cacheTTL:            c.cacheTTL,
+		cacheTTLs:           maps.Clone(c.cacheTTLs),
+		providers:           c.providers,
+		webhookKeys:         c.webhookKeys,
+		sharedTransport:     true,
+		err:                 c.err,
+	}
+	for _, opt := range opts {
+		opt(clone)
+	}
+	
+	// Capabilities and webhook keys belong to the host and account they
+	// were fetched from
+	if clone.baseURL != c.baseURL || clone.onBehalfOf != c.onBehalfOf {
+		clone.providers = &providerCache{}
+		clone.webhookKeys = &webhookKeyCache{}
+	}
+	
+	return clone
+}
+
+// keyMode validates an API key's format and returns its mode
+func keyMode(apiKey string) (string, error) {
+	if apiKey == "" {
+		return "", ErrMissingAPIKey
+	}
+	
+	if !strings.HasPrefix(apiKey, "sk_test_") && !strings.HasPrefix(apiKey, "sk_live_") {
+		return "", ErrInvalidAPIKey
+	}
+	
+	if strings.HasPrefix(apiKey, "sk_test_") {
+		return "test", nil
+	}
+	return "live", nil
+}
+
+// SetAPIKey swaps the API key used for subsequent requests by c and every
+// client cloned from it (or that it was cloned from), e.g. when a secret
+// manager rotates it. Requests already in flight keep the old key.
+// The new key must have the same mode (test or live) as the current one,
+// so it fails with ErrModeMismatch on clients using WithBearerToken.
+func (c *Client) SetAPIKey(apiKey string) error {
+	mode, err := keyMode(apiKey)
+	if err != nil {
+		return err
+	}
+	if mode != c.mode {
+		return ErrModeMismatch
+	}
+	c.apiKey.Store(apiKey)
+	return nil
+}
+
 // ClientOption is a function that configures the client
 type ClientOption func(*Client)
 
+// TokenSource supplies bearer tokens, e.g. short-lived OAuth2 access
+// tokens. Token is called for every request, so implementations should
+// cache the token and refresh it only when it nears expiry.
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// TokenSourceFunc adapts a function to TokenSource. A
+// golang.org/x/oauth2 TokenSource can be wrapped as:
+//
+//	shegerpay.TokenSourceFunc(func(ctx context.Context) (string, error) {
+//		tok, err := ts.Token()
+//		if err != nil {
+//			return "", err
+//		}
+//		return tok.AccessToken, nil
+//	})
+type TokenSourceFunc func(ctx context.Context) (string, error)
+
+// Token calls f(ctx)
+func (f TokenSourceFunc) Token(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// WithBearerToken authenticates requests with tokens from source instead
+// of an API key. The API key passed to NewClient is then not validated
+// and may be empty.
+func WithBearerToken(source TokenSource) ClientOption {
+	return func(c *Client) {
+		c.tokenSource = source
+	}
+}
+
 // WithBaseURL sets a custom base URL
 func WithBaseURL(url string) ClientOption {
 	return func(c *Client) {
@@ -105,6 +585,20 @@ func WithBaseURL(url string) ClientOption {
 	}
 }
 
+// WithEnvironment points the client at a known environment such as
+// EnvProduction; unknown names are an error. Use WithBaseURL for custom
+// endpoints like local mocks.
+func WithEnvironment(env string) ClientOption {
+	return func(c *Client) {
+		baseURL, ok := environmentURLs[env]
+		if !ok {
+			c.err = fmt.Errorf("unknown environment %q", env)
+			return
+		}
+		c.baseURL = baseURL
+	}
+}
+
 // WithTimeout sets request timeout
 func WithTimeout(d time.Duration) ClientOption {
 	return func(c *Client) {
@@ -112,56 +606,395 @@ func WithTimeout(d time.Duration) ClientOption {
 	}
 }
 
+// WithDefaultMerchantName sets the merchant name used by Verify when
+// VerifyParams.MerchantName is empty
+func WithDefaultMerchantName(name string) ClientOption {
+	return func(c *Client) {
+		c.defaultMerchantName = name
+	}
+}
+
+// WithLocale asks the server to localize human-readable strings such as
+// VerificationResult.Reason, e.g. "en" or "am" (Amharic), by sending an
+// Accept-Language header. No header is sent by default.
+func WithLocale(lang string) ClientOption {
+	return func(c *Client) {
+		c.locale = lang
+	}
+}
+
+// WithOnBehalfOf attributes every request to a sub-merchant via the
+// On-Behalf-Of header, for resellers operating many merchants under one
+// key. Combine with Clone for a per-tenant client:
+//
+//	tenant := client.Clone(shegerpay.WithOnBehalfOf("merchant_123"))
+//	usage, err := tenant.GetAPIUsage() // usage of merchant_123 only
+func WithOnBehalfOf(merchantID string) ClientOption {
+	return func(c *Client) {
+		c.onBehalfOf = merchantID
+	}
+}
+
+// WithFallbackProvider sets the provider QuickVerify hints to the server
+// for transaction IDs it cannot detect, e.g. ProviderTelebirr when all
+// your payments arrive through it
+func WithFallbackProvider(provider Provider) ClientOption {
+	return func(c *Client) {
+		c.fallbackProvider = provider.normalize()
+	}
+}
+
+// WithHeader adds a static header, e.g. X-Tenant-ID, to every request.
+// It may be repeated. The Authorization header is reserved and cannot
+// be set this way.
+func WithHeader(key, value string) ClientOption {
+	return func(c *Client) {
+		if http.CanonicalHeaderKey(key) == "Authorization" {
+			c.err = fmt.Errorf("header %q is reserved", key)
+			return
+		}
+		if c.headers == nil {
+			c.headers = http.Header{}
+		}
+		c.headers.Add(key, value)
+	}
+}
+
+// WithRequestSigning signs every request body with secret, sending the
+// hex HMAC-SHA256 of the exact body bytes in an X-Signature header, for
+// accounts that require signed requests. Requests without a body are
+// not signed.
+func WithRequestSigning(secret string) ClientOption {
+	return func(c *Client) {
+		c.signingSecret = secret
+	}
+}
+
+// WithStrictDecoding makes responses containing fields the SDK's types do
+// not model fail to decode, to catch API contract drift in tests. Off by
+// default so benign server additions do not break clients.
+func WithStrictDecoding() ClientOption {
+	return func(c *Client) {
+		c.strictDecoding = true
+	}
+}
+
+// WithUseNumber decodes numbers in untyped results, such as the maps
+// returned by GetWalletBalance, as json.Number instead of float64, so
+// large amounts keep every digit. Typed results expose exact amounts via
+// methods like VerificationResult.ExactAmount.
+func WithUseNumber() ClientOption {
+	return func(c *Client) {
+		c.useNumber = true
+	}
+}
+
+// WithSlog emits a structured log entry for every API call. The API key
+// and Authorization header are never logged.
+func WithSlog(logger *slog.Logger) ClientOption {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// RequestInfo describes a completed API call
+type RequestInfo struct {
+	Method     string
+	Path       string
+	StatusCode int // 0 if no response was received
+	Duration   time.Duration
+	RequestID  string
+	RetryCount int   // 0 for the first attempt
+	Err        error // transport error, if any
+
+	// Body is the response body. It is shared with the SDK's decoding
+	// and must not be modified.
+	Body []byte
+}
+
+// WithRequestHook registers fn to be called after every API call, e.g. to
+// record latency and status metrics per endpoint
+func WithRequestHook(fn func(RequestInfo)) ClientOption {
+	return func(c *Client) {
+		c.hook = fn
+	}
+}
+
+// WithProxy routes requests through an HTTP proxy, e.g. "http://proxy:8080"
+func WithProxy(proxyURL string) ClientOption {
+	return func(c *Client) {
+		parsed, err := url.Parse(proxyURL)
+		if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			c.err = fmt.Errorf("invalid proxy URL %q", proxyURL)
+			return
+		}
+		c.transport().Proxy = http.ProxyURL(parsed)
+	}
+}
+
+// WithTLSConfig installs a TLS configuration on the client's transport,
+// e.g. to present a client certificate (mTLS) or pin the server CA.
+// The client's timeouts are left untouched.
+func WithTLSConfig(config *tls.Config) ClientOption {
+	return func(c *Client) {
+		c.transport().TLSClientConfig = config
+	}
+}
+
+// WithDialTimeout bounds DNS resolution and connection setup, so a bad
+// network fails fast instead of consuming the overall timeout
+func WithDialTimeout(d time.Duration) ClientOption {
+	return func(c *Client) {
+		c.transport().DialContext = (&net.Dialer{
+			Timeout:   d,
+			KeepAlive: 30 * time.Second,
+		}).DialContext
+	}
+}
+
+// WithResponseHeaderTimeout bounds the wait for response headers after
+// the request has been written
+func WithResponseHeaderTimeout(d time.Duration) ClientOption {
+	return func(c *Client) {
+		c.transport().ResponseHeaderTimeout = d
+	}
+}
+
+// transport returns the client's *http.Transport, installing a clone of
+// http.DefaultTransport the first time it is needed. A transport shared
+// with another client is copied first so changes stay local.
+func (c *Client) transport() *http.Transport {
+	t, ok := c.http.Transport.(*http.Transport)
+	if ok && !c.sharedTransport {
+		return t
+	}
+	if !ok {
+		t = http.DefaultTransport.(*http.Transport)
+	}
+	t = t.Clone()
+	c.sharedTransport = false
+	c.http.Transport = t
+	return t
+}
+
+// Close releases idle connections held by the client's transport.
+// The client must not be used after Close.
+func (c *Client) Close() error {
+	c.http.CloseIdleConnections()
+	return nil
+}
+
 // Verify verifies a payment transaction
 func (c *Client) Verify(params VerifyParams) (*VerificationResult, error) {
-	if params.TransactionID == "" {
-		return nil, errors.New("TransactionID is required")
+	return c.VerifyContext(context.Background(), params)
+}
+
+// VerifyContext verifies a payment transaction, honoring ctx cancellation
+// and deadline across retries
+func (c *Client) VerifyContext(ctx context.Context, params VerifyParams) (*VerificationResult, error) {
+	if params.TransactionID == "" && params.OrderReference == "" {
+		return nil, newValidationError("TransactionID", "TransactionID or OrderReference is required")
 	}
 	if params.Amount <= 0 {
-		return nil, errors.New("Amount is required")
+		return nil, newValidationError("Amount", "Amount is required")
 	}
-	
-	// Auto-detect provider
-	provider := params.Provider
-	if provider == "" {
-		if strings.HasPrefix(strings.ToUpper(params.TransactionID), "FT") {
-			provider = "cbe"
-		} else {
-			provider = "telebirr"
+	if params.AmountTolerance < 0 {
+		return nil, newValidationError("AmountTolerance", "AmountTolerance must not be negative")
+	}
+	if params.AmountTolerancePercent < 0 {
+		return nil, newValidationError("AmountTolerancePercent", "AmountTolerancePercent must not be negative")
+	}
+	if params.AmountTolerance > 0 && params.AmountTolerancePercent > 0 {
+		return nil, newValidationError("AmountTolerance", "set either AmountTolerance or AmountTolerancePercent, not both")
+	}
+	if params.Currency != "" {
+		currency, err := normalizeCurrency("Currency", params.Currency)
+		if err != nil {
+			return nil, err
 		}
+		params.Currency = currency
+	}
+	
+	// Auto-detect provider unless the caller defers to the server. An
+	// order reference alone carries no hint, so the server decides.
+	provider := params.Provider.normalize()
+	switch {
+	case provider != "":
+	case params.TransactionID == "":
+		provider = ProviderAuto
+	case strings.HasPrefix(strings.ToUpper(params.TransactionID), "FT"):
+		provider = ProviderCBE
+	default:
+		provider = ProviderTelebirr
 	}
 	
 	merchantName := params.MerchantName
 	if merchantName == "" {
-		merchantName = "ShegerPay Verification"
+		merchantName = c.defaultMerchantName
 	}
 	
 	data := url.Values{}
-	data.Set("provider", provider)
-	data.Set("transaction_id", params.TransactionID)
+	if provider != ProviderAuto {
+		data.Set("provider", string(provider))
+	}
+	if params.TransactionID != "" {
+		data.Set("transaction_id", params.TransactionID)
+	}
+	if params.OrderReference != "" {
+		data.Set("order_reference", params.OrderReference)
+	}
 	data.Set("amount", fmt.Sprintf("%f", params.Amount))
+	if params.Currency != "" {
+		data.Set("currency", params.Currency)
+	}
 	data.Set("merchant_name", merchantName)
 	
 	if params.SubProvider != "" {
+		if provider != ProviderAuto {
+			if err := c.validateSubProvider(ctx, provider, params.SubProvider); err != nil {
+				return nil, err
+			}
+		}
 		data.Set("sub_provider", params.SubProvider)
 	}
+	if params.AmountTolerance > 0 {
+		data.Set("amount_tolerance", fmt.Sprintf("%f", params.AmountTolerance))
+	}
+	if params.AmountTolerancePercent > 0 {
+		data.Set("amount_tolerance_percent", fmt.Sprintf("%f", params.AmountTolerancePercent))
+	}
+	
+	for key, value := range params.Metadata {
+		data.Set("metadata["+key+"]", value)
+	}
+	if params.IncludeReceipt {
+		data.Set("include_receipt", "true")
+	}
+	
+	cacheKey, cacheable := c.verifyCacheKey(data)
+	if cacheable {
+		if cached, ok := c.verifyCache.get(cacheKey); ok {
+			return cached, nil
+		}
+	}
 	
 	result := &VerificationResult{}
-	err := c.request("POST", "/api/v1/verify", data, result)
+	resp, err := c.send(ctx, "POST", "/api/v1/verify", data, result)
+	result.setResponse(resp)
+	if err == nil && cacheable {
+		c.verifyCache.set(cacheKey, result)
+	}
+	return result, err
+}
+
+// VerifyAny verifies a transaction against several candidate amounts,
+// e.g. with and without a fee, and returns the first result that is
+// valid with MatchedAmount set. If none match, the last result is
+// returned. Candidates are tried in order and the search stops at the
+// first API error.
+func (c *Client) VerifyAny(ctx context.Context, transactionID string, amounts []float64) (*VerificationResult, error) {
+	if len(amounts) == 0 {
+		return nil, newValidationError("amounts", "at least one candidate amount is required")
+	}
+	
+	var result *VerificationResult
+	for _, amount := range amounts {
+		var err error
+		result, err = c.VerifyContext(ctx, VerifyParams{TransactionID: transactionID, Amount: amount})
+		if err != nil {
+			return result, err
+		}
+		if result.Valid {
+			result.MatchedAmount = amount
+			return result, nil
+		}
+	}
+	return result, nil
+}
+
+// PayerLinkResult reports whether several transactions came from the
+// same payer account
+type PayerLinkResult struct {
+	SamePayer bool `json:"same_payer"`
+
+	// Confidence is the server's certainty in SamePayer, from 0 to 1
+	Confidence float64 `json:"confidence"`
+
+	// PayerID is an opaque identifier of the shared payer, set when
+	// SamePayer is true. It is stable, so it can be stored for correlation.
+	PayerID string `json:"payer_id,omitempty"`
+
+	// Unresolved lists transaction IDs whose payer could not be determined
+	Unresolved []string `json:"unresolved,omitempty"`
+}
+
+// CheckSamePayer checks whether all transactionIDs originate from the same
+// payer account, e.g. for fraud and risk checks. At least two IDs are
+// required.
+func (c *Client) CheckSamePayer(ctx context.Context, transactionIDs []string) (*PayerLinkResult, error) {
+	if len(transactionIDs) < 2 {
+		return nil, newValidationError("transactionIDs", "at least two transaction IDs are required")
+	}
+	for _, id := range transactionIDs {
+		if id == "" {
+			return nil, newValidationError("transactionIDs", "transaction IDs must not be empty")
+		}
+	}
+	
+	data := url.Values{"transaction_ids": transactionIDs}
+	
+	result := &PayerLinkResult{}
+	err := c.requestContext(ctx, "POST", "/api/v1/verify/payer-link", data, result)
 	return result, err
 }
 
 // QuickVerify verifies with auto-detected provider
 func (c *Client) QuickVerify(transactionID string, amount float64) (*VerificationResult, error) {
+	return c.QuickVerifyContext(context.Background(), transactionID, amount)
+}
+
+// QuickVerifyContext verifies with auto-detected provider, honoring ctx
+// cancellation and deadline across retries
+func (c *Client) QuickVerifyContext(ctx context.Context, transactionID string, amount float64) (*VerificationResult, error) {
 	data := url.Values{}
 	data.Set("transaction_id", transactionID)
 	data.Set("amount", fmt.Sprintf("%f", amount))
+	if c.fallbackProvider != "" {
+		data.Set("fallback_provider", string(c.fallbackProvider))
+	}
 	
 	result := &VerificationResult{}
-	err := c.request("POST", "/api/v1/quick-verify", data, result)
+	resp, err := c.send(ctx, "POST", "/api/v1/quick-verify", data, result)
+	result.setResponse(resp)
 	return result, err
 }
 
+// DoRaw sends an authenticated request and returns the untouched response,
+// whatever its status. No retries or JSON decoding are applied; the caller
+// must close the response body. Use it for endpoints or fields the SDK
+// does not model yet.
+func (c *Client) DoRaw(ctx context.Context, method, path string, body url.Values) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = strings.NewReader(body.Encode())
+	}
+	
+	req, err := c.newRequest(ctx, method, path, reader)
+	if err != nil {
+		return nil, err
+	}
+	
+	start := time.Now()
+	resp, err := c.http.Do(req)
+	info := RequestInfo{Method: method, Path: path, Duration: time.Since(start), Err: err}
+	if resp != nil {
+		info.StatusCode = resp.StatusCode
+		info.RequestID = resp.Header.Get("X-Request-ID")
+	}
+	c.observe(ctx, info)
+	return resp, err
+}
+
 // GetHistory gets transaction history
 func (c *Client) GetHistory() ([]map[string]interface{}, error) {
 	var result []map[string]interface{}
@@ -169,49 +1002,380 @@ func (c *Client) GetHistory() ([]map[string]interface{}, error) {
 	return result, err
 }
 
+// GetHistoryFiltered gets transaction history narrowed by provider,
+// status, time range and limit, e.g. today's failed CBE verifications
+func (c *Client) GetHistoryFiltered(ctx context.Context, filter HistoryFilter) ([]map[string]interface{}, error) {
+	filter.Cursor = ""
+	path := "/api/v1/history"
+	if query := filter.values().Encode(); query != "" {
+		path += "?" + query
+	}
+	
+	var result []map[string]interface{}
+	err := c.requestContext(ctx, "GET", path, nil, &result)
+	return result, err
+}
+
+// GetHistoryPage gets a single page of transaction history
+func (c *Client) GetHistoryPage(ctx context.Context, filter HistoryFilter) (*HistoryPage, error) {
+	path := "/api/v1/transactions/history"
+	if query := filter.values().Encode(); query != "" {
+		path += "?" + query
+	}
+	
+	result := &HistoryPage{}
+	err := c.requestContext(ctx, "GET", path, nil, result)
+	return result, err
+}
+
+// statusBatchSize is the number of transaction IDs sent per request by
+// GetTransactionStatuses
+const statusBatchSize = 100
+
+// GetTransactionStatuses gets the current status of each transaction ID,
+// e.g. to confirm during reconciliation that none was reversed, without
+// re-running verification. Long lists are split into several requests.
+// IDs unknown to the server are absent from the map.
+func (c *Client) GetTransactionStatuses(ctx context.Context, transactionIDs []string) (map[string]string, error) {
+	statuses := make(map[string]string, len(transactionIDs))
+	for start := 0; start < len(transactionIDs); start += statusBatchSize {
+		end := min(start+statusBatchSize, len(transactionIDs))
+		data := url.Values{"transaction_ids": transactionIDs[start:end]}
+		
+		var result struct {
+			Statuses map[string]string `json:"statuses"`
+		}
+		if err := c.requestContext(ctx, "POST", "/api/v1/transactions/statuses", data, &result); err != nil {
+			return nil, err
+		}
+		for id, status := range result.Statuses {
+			statuses[id] = status
+		}
+	}
+	return statuses, nil
+}
+
+// HistoryIterator returns an iterator over the full transaction history,
+// fetching pages lazily as the caller advances
+func (c *Client) HistoryIterator(ctx context.Context, filter HistoryFilter) *Iterator[Transaction] {
+	return newIterator(ctx, c, filter.Cursor, func(ctx context.Context, cursor string) (*Page[Transaction], error) {
+		filter.Cursor = cursor
+		return c.GetHistoryPage(ctx, filter)
+	})
+}
+
 func (c *Client) request(method, path string, data url.Values, result interface{}) error {
-	fullURL := c.baseURL + path
+	return c.requestContext(context.Background(), method, path, data, result)
+}
+
+func (c *Client) requestContext(ctx context.Context, method, path string, data url.Values, result interface{}) error {
+	_, err := c.send(ctx, method, path, data, result)
+	return err
+}
+
+// response describes the HTTP exchange behind a decoded result
+type response struct {
+	statusCode int
+	header     http.Header
+	duration   time.Duration
+	body       []byte
+}
+
+// replayed reports whether the server signalled an idempotent replay
+func (r *response) replayed() bool {
+	return strings.EqualFold(r.header.Get("X-Idempotency-Replayed"), "true")
+}
+
+// send performs the request, retrying transient failures as configured
+// by WithRetry, and decodes a successful body into result. The returned
+// response is non-nil whenever the server replied.
+func (c *Client) send(ctx context.Context, method, path string, data url.Values, result interface{}) (*response, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
 	
-	var body io.Reader
+	var encoded *string
 	if data != nil {
-		body = strings.NewReader(data.Encode())
+		body := data.Encode()
+		encoded = &body
+	}
+	
+	cacheKey, cacheTTL := c.cacheEntry(method, path)
+	if cacheTTL > 0 {
+		if body, ok := c.cache.Get(cacheKey); ok {
+			return &response{statusCode: http.StatusOK, body: body}, c.decodeResponse("", body, result)
+		}
 	}
 	
-	req, err := http.NewRequest(method, fullURL, body)
+	ctx, etagKey := c.conditional(ctx, method, path)
+	c.retryBudget.deposit()
+	
+	var attempts []AttemptInfo
+	for attempt := 0; ; attempt++ {
+		var probe bool
+		if c.breaker != nil {
+			var err error
+			if probe, err = c.breaker.allow(); err != nil {
+				return nil, err
+			}
+		}
+		
+		start := time.Now()
+		resp, err := c.attempt(ctx, method, path, encoded, attempt, result)
+		if c.breaker != nil {
+			c.breaker.record(ctx, probe, resp, err)
+		}
+		info := AttemptInfo{Duration: time.Since(start), Err: err}
+		if resp != nil {
+			info.StatusCode = resp.statusCode
+		}
+		attempts = append(attempts, info)
+		recordAttempts(err, attempts)
+		
+		wait, retry := c.shouldRetry(ctx, method, path, attempt, err)
+		if !retry {
+			if err == nil && etagKey != "" {
+				err = c.revalidate(etagKey, resp, result)
+			}
+			if err == nil && cacheTTL > 0 && len(resp.body) > 0 {
+				c.cache.Set(cacheKey, resp.body, cacheTTL)
+			}
+			return resp, err
+		}
+		
+		// Stop early rather than sleep past the caller's deadline
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return resp, fmt.Errorf("retry would exceed context deadline: %w: %w", context.DeadlineExceeded, err)
+		}
+		if !c.retryBudget.withdraw() {
+			return resp, err
+		}
+		if sleepErr := sleepContext(ctx, wait); sleepErr != nil {
+			return resp, fmt.Errorf("retry interrupted: %w: %w", sleepErr, err)
+		}
+	}
+}
+
+// newRequest builds an authenticated API request
+func (c *Client) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	
+	var signature string
+	if c.signingSecret != "" && body != nil {
+		payload, err := io.ReadAll(body)
+		if err != nil {
+			return nil, err
+		}
+		mac := hmac.New(sha256.New, []byte(c.signingSecret))
+		mac.Write(payload)
+		signature = hex.EncodeToString(mac.Sum(nil))
+		body = bytes.NewReader(payload)
+	}
+	
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	for key, values := range c.headers {
+		req.Header[key] = append([]string(nil), values...)
+	}
+	for key, values := range requestHeaders(ctx) {
+		req.Header[key] = append([]string(nil), values...)
+	}
+	token := c.apiKey.Load().(string)
+	if c.tokenSource != nil {
+		token, err = c.tokenSource.Token(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("get bearer token: %w", redactedError{err})
+		}
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
 	req.Header.Set("User-Agent", "ShegerPay-Go-SDK/1.0")
+	if c.locale != "" {
+		req.Header.Set("Accept-Language", c.locale)
+	}
+	if c.onBehalfOf != "" {
+		req.Header.Set("On-Behalf-Of", c.onBehalfOf)
+	}
 	if method == "POST" {
 		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	}
+	if signature != "" {
+		req.Header.Set("X-Signature", signature)
+	}
+	return req, nil
+}
+
+// recordAttempts attaches the attempt history to an APIError
+func recordAttempts(err error, attempts []AttemptInfo) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		apiErr.RetryCount = len(attempts) - 1
+		
+		// Keeping err as the last Err would make the error contain
+		// itself, sending anything that walks Attempts into a loop
+		apiErr.Attempts = slices.Clone(attempts)
+		apiErr.Attempts[len(attempts)-1].Err = nil
+	}
+}
+
+// attempt performs a single HTTP round trip
+func (c *Client) attempt(ctx context.Context, method, path string, encoded *string, retryCount int, result interface{}) (*response, error) {
+	var body io.Reader
+	if encoded != nil {
+		body = strings.NewReader(*encoded)
+	}
 	
-	resp, err := c.http.Do(req)
+	req, err := c.newRequest(ctx, method, path, body)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	
+	httpClient := c.http
+	if timeout := c.policy(method, path).Timeout; timeout != httpClient.Timeout {
+		withTimeout := *c.http
+		withTimeout.Timeout = timeout
+		httpClient = &withTimeout
+	}
+	
+	start := time.Now()
+	resp, err := httpClient.Do(req)
+	duration := time.Since(start)
+	if err != nil {
+		c.observe(ctx, RequestInfo{Method: method, Path: path, Duration: duration, RetryCount: retryCount, Err: err})
+		return nil, err
 	}
 	defer resp.Body.Close()
 	
+	// Read the body exactly once; hooks, logging, error handling and
+	// decoding all work on this buffer rather than the live stream
 	respBody, err := io.ReadAll(resp.Body)
+	c.observe(ctx, RequestInfo{
+		Method:     method,
+		Path:       path,
+		StatusCode: resp.StatusCode,
+		Duration:   duration,
+		RequestID:  resp.Header.Get("X-Request-ID"),
+		RetryCount: retryCount,
+		Body:       respBody,
+		Err:        err,
+	})
+	
+	meta := &response{statusCode: resp.StatusCode, header: resp.Header, duration: duration, body: respBody}
 	if err != nil {
-		return err
+		return meta, err
 	}
 	
-	if resp.StatusCode == 401 {
-		return errors.New("invalid API key")
+	if resp.StatusCode >= 400 {
+		apiErr := newAPIError(resp, respBody)
+		apiErr.Duration = duration
+		return meta, apiErr
 	}
-	if resp.StatusCode == 400 {
-		var errResp map[string]string
-		json.Unmarshal(respBody, &errResp)
-		return errors.New(errResp["detail"])
+	
+	// 204 No Content and empty bodies leave result untouched
+	if resp.StatusCode == http.StatusNoContent || len(bytes.TrimSpace(respBody)) == 0 {
+		return meta, nil
 	}
 	
-	return json.Unmarshal(respBody, result)
+	return meta, c.decodeResponse(resp.Header.Get("Content-Type"), respBody, result)
+}
+
+// maxErrorSnippet caps the body excerpt included in decoding errors
+const maxErrorSnippet = 200
+
+// decodeResponse decodes a successful JSON body. Non-JSON content, such
+// as an HTML block page from a proxy or WAF, is reported with its content
+// type and a snippet of the body. A missing Content-Type is tolerated.
+func (c *Client) decodeResponse(contentType string, body []byte, result interface{}) error {
+	if contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || (mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json")) {
+			return fmt.Errorf("%w %q: %s", ErrUnexpectedContentType, contentType, truncate(redact(string(body)), maxErrorSnippet))
+		}
+	}
+	
+	if !c.strictDecoding && !c.useNumber {
+		return json.Unmarshal(body, result)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	if c.strictDecoding {
+		decoder.DisallowUnknownFields()
+	}
+	if c.useNumber {
+		decoder.UseNumber()
+	}
+	return decoder.Decode(result)
+}
+
+// rawNumber returns the numeric field of a JSON object exactly as written,
+// or "" if it is absent or not a number
+func rawNumber(raw json.RawMessage, field string) json.Number {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return ""
+	}
+	var number json.Number
+	if err := json.Unmarshal(fields[field], &number); err != nil {
+		return ""
+	}
+	return number
+}
+
+// maxLoggedBody caps the response body included in debug log entries
+const maxLoggedBody = 2048
+
+// truncate shortens s to at most n bytes, marking the cut
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "...(truncated)"
+}
+
+// observe reports a completed call to the request hook and logger. Log
+// entries are debug for success, warn for client errors, and error for
+// server and transport failures.
+func (c *Client) observe(ctx context.Context, info RequestInfo) {
+	if c.hook != nil {
+		c.hook(info)
+	}
+	if c.logger == nil {
+		return
+	}
+	
+	level := slog.LevelDebug
+	switch {
+	case info.Err != nil || info.StatusCode >= 500:
+		level = slog.LevelError
+	case info.StatusCode >= 400:
+		level = slog.LevelWarn
+	}
+	
+	attrs := []slog.Attr{
+		slog.String("method", info.Method),
+		slog.String("path", info.Path),
+		slog.Int("status", info.StatusCode),
+		slog.Duration("duration", info.Duration),
+		slog.Int("retry_count", info.RetryCount),
+	}
+	if info.RequestID != "" {
+		attrs = append(attrs, slog.String("request_id", info.RequestID))
+	}
+	if info.Err != nil {
+		attrs = append(attrs, slog.String("error", redact(info.Err.Error())))
+	}
+	if len(info.Body) > 0 && c.logger.Enabled(ctx, slog.LevelDebug) {
+		attrs = append(attrs, slog.String("body", truncate(redact(string(info.Body)), maxLoggedBody)))
+	}
+	c.logger.LogAttrs(ctx, level, "shegerpay request", attrs...)
 }
 
-// VerifyWebhookSignature verifies a webhook signature
+// VerifyWebhookSignature verifies a webhook signature. It expects the
+// pre-split "sha256=<hex>" value; use VerifyWebhookSignatureHeader for the
+// raw "t=...,v1=..." header.
 func VerifyWebhookSignature(payload, signature, secret string) bool {
 	mac := hmac.New(sha256.New, []byte(secret))
 	mac.Write([]byte(payload))
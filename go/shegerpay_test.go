@@ -0,0 +1,173 @@
+package shegerpay
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testAPIKey is the key test clients authenticate with
+const testAPIKey = "sk_test_0123456789abcdef"
+
+// newTestClient returns a client talking to a test server running handler
+func newTestClient(t *testing.T, handler http.HandlerFunc, opts ...ClientOption) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(testAPIKey, append([]ClientOption{WithBaseURL(server.URL)}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+// writeJSON writes body as a JSON response with the given status
+func writeJSON(w http.ResponseWriter, status int, body string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	io.WriteString(w, body)
+}
+
+func TestIsFinal(t *testing.T) {
+	tests := []struct {
+		result VerificationResult
+		want   bool
+	}{
+		{VerificationResult{Status: StatusVerified, StatusCode: http.StatusOK}, true},
+		{VerificationResult{Status: StatusFailed, StatusCode: http.StatusOK}, true},
+		{VerificationResult{Status: StatusPending, StatusCode: http.StatusOK}, false},
+		{VerificationResult{Status: StatusError, StatusCode: http.StatusOK}, false},
+		{VerificationResult{Status: "", StatusCode: http.StatusOK}, false},
+		{VerificationResult{Status: StatusVerified, StatusCode: http.StatusAccepted}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.result.IsFinal(); got != tt.want {
+			t.Errorf("IsFinal(status %q, code %d) = %v, want %v", tt.result.Status, tt.result.StatusCode, got, tt.want)
+		}
+	}
+}
+
+func TestSetAPIKeyReachesClones(t *testing.T) {
+	const rotated = "sk_test_rotated"
+	var got []string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("Authorization"))
+		writeJSON(w, http.StatusOK, `[]`)
+	})
+	clone := client.Clone(WithTimeout(time.Minute))
+
+	if err := client.SetAPIKey(rotated); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := clone.ListWebhooks(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0] != "Bearer "+rotated {
+		t.Errorf("clone sent Authorization %q, want the rotated key", got)
+	}
+}
+
+func TestWithEnvironment(t *testing.T) {
+	client, err := NewClient(testAPIKey, WithEnvironment(EnvProduction))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.baseURL != "https://api.shegerpay.com" {
+		t.Errorf("baseURL = %q, want the production API", client.baseURL)
+	}
+
+	for _, env := range []string{"sandbox", "staging", ""} {
+		if _, err := NewClient(testAPIKey, WithEnvironment(env)); err == nil {
+			t.Errorf("WithEnvironment(%q) was accepted", env)
+		}
+	}
+}
+
+func TestWithTLSConfig(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, `[]`)
+	}))
+	defer server.Close()
+
+	untrusted, err := NewClient(testAPIKey, WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := untrusted.ListWebhooks(context.Background()); err == nil {
+		t.Error("request to a server with an untrusted certificate succeeded")
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+	client, err := NewClient(testAPIKey, WithBaseURL(server.URL), WithTLSConfig(&tls.Config{RootCAs: roots}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.ListWebhooks(context.Background()); err != nil {
+		t.Errorf("request with the server's CA trusted: %v", err)
+	}
+}
+
+func TestLoggingKeepsBodyForResult(t *testing.T) {
+	var logs bytes.Buffer
+	var hooked []byte
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, `{"valid":true,"status":"verified","transaction_id":"FT123"}`)
+	},
+		WithSlog(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))),
+		WithRequestHook(func(info RequestInfo) { hooked = info.Body }),
+	)
+
+	result, err := client.VerifyContext(context.Background(), VerifyParams{Provider: ProviderCBE, TransactionID: "FT123", Amount: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Valid || result.TransactionID != "FT123" {
+		t.Errorf("result = %+v, want the decoded body", result)
+	}
+	if !strings.Contains(logs.String(), "FT123") {
+		t.Errorf("log output %q does not include the body", logs.String())
+	}
+	if !bytes.Contains(hooked, []byte("FT123")) {
+		t.Errorf("hook saw body %q, want the response body", hooked)
+	}
+}
+
+func TestExactAmountRoundTrip(t *testing.T) {
+	const exact = "9007199254740993" // 2^53 + 1, not representable as float64
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, `{"valid":true,"status":"verified","amount":`+exact+`,"actual_amount":`+exact+`}`)
+	}, WithUseNumber())
+
+	result, err := client.VerifyContext(context.Background(), VerifyParams{Provider: ProviderCBE, TransactionID: "FT123", Amount: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := result.ExactAmount(); got.String() != exact {
+		t.Errorf("ExactAmount = %q, want %q", got, exact)
+	}
+	if got := result.ExactActualAmount(); got.String() != exact {
+		t.Errorf("ExactActualAmount = %q, want %q", got, exact)
+	}
+	if n, err := result.ExactAmount().Int64(); err != nil || n != 9007199254740993 {
+		t.Errorf("ExactAmount().Int64() = %d, %v", n, err)
+	}
+
+	refund, err := client.ApproveRefund("rf_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := refund["amount"].(json.Number); !ok || got.String() != exact {
+		t.Errorf("WithUseNumber decoded amount as %#v, want json.Number %s", refund["amount"], exact)
+	}
+}
@@ -1,3 +1,14 @@
+package shegerpay
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"strconv"
+	"strings"
+)
 
 // ============================================
 // WALLET METHODS
@@ -10,7 +21,8 @@ func (c *Client) GetWalletBalance() (map[string]interface{}, error) {
 	return result, err
 }
 
-// ConvertCurrency converts currency within wallet
+// ConvertCurrency converts currency within wallet. It is not idempotent;
+// prefer ConvertCurrencyWithParams with an IdempotencyKey.
 func (c *Client) ConvertCurrency(from, to string, amount float64) (map[string]interface{}, error) {
 	data := url.Values{}
 	data.Set("from_currency", from)
@@ -22,12 +34,122 @@ func (c *Client) ConvertCurrency(from, to string, amount float64) (map[string]in
 	return result, err
 }
 
+// ConvertCurrencyParams contains parameters for a wallet conversion
+type ConvertCurrencyParams struct {
+	From   string // ISO 4217 code, e.g. "USD"
+	To     string // ISO 4217 code, e.g. "ETB"
+	Amount float64
+	
+	// MinReceived makes the server reject the conversion instead of
+	// executing it if it would yield less than this amount of To.
+	// 0 disables the check.
+	MinReceived float64
+
+	// IdempotencyKey lets the server execute the conversion at most once,
+	// however often it is submitted. Always set it, e.g. from
+	// NewIdempotencyKey stored with your transfer record, and reuse it
+	// when retrying after a timeout: without it a retried conversion can
+	// move funds twice. If empty, a key is generated per call, which only
+	// protects the SDK's own retries.
+	IdempotencyKey string
+}
+
+// Conversion is the outcome of a wallet conversion
+type Conversion struct {
+	ID              string  `json:"id,omitempty"`
+	FromCurrency    string  `json:"from_currency"`
+	ToCurrency      string  `json:"to_currency"`
+	Amount          float64 `json:"amount"`
+	ConvertedAmount float64 `json:"converted_amount"`
+	Rate            float64 `json:"rate"`
+	Status          string  `json:"status,omitempty"`
+
+	// Replayed is true when the server returned the earlier conversion for
+	// a repeated IdempotencyKey rather than converting again
+	Replayed bool `json:"-"`
+}
+
+// ConvertCurrencyWithParams converts currency within wallet, optionally
+// guaranteeing a minimum received amount. Set params.IdempotencyKey so
+// the conversion can be retried safely.
+func (c *Client) ConvertCurrencyWithParams(ctx context.Context, params ConvertCurrencyParams) (*Conversion, error) {
+	from, err := normalizeCurrency("From", params.From)
+	if err != nil {
+		return nil, err
+	}
+	to, err := normalizeCurrency("To", params.To)
+	if err != nil {
+		return nil, err
+	}
+	if params.Amount <= 0 {
+		return nil, newValidationError("Amount", "Amount is required")
+	}
+	if params.MinReceived < 0 {
+		return nil, newValidationError("MinReceived", "MinReceived must not be negative")
+	}
+	
+	data := url.Values{}
+	data.Set("from_currency", from)
+	data.Set("to_currency", to)
+	data.Set("amount", fmt.Sprintf("%f", params.Amount))
+	if params.MinReceived > 0 {
+		data.Set("min_received", fmt.Sprintf("%f", params.MinReceived))
+	}
+	
+	idempotencyKey := params.IdempotencyKey
+	if idempotencyKey == "" {
+		idempotencyKey = NewIdempotencyKey()
+	}
+	ctx = withRequestHeader(ctx, IdempotencyKeyHeader, idempotencyKey)
+	
+	result := &Conversion{}
+	resp, err := c.send(ctx, "POST", "/api/v1/wallets/convert", data, result)
+	if resp != nil {
+		result.Replayed = resp.replayed()
+	}
+	return result, err
+}
+
+// normalizeCurrency upper-cases a currency code and checks it is a
+// three-letter ISO 4217 code
+func normalizeCurrency(field, code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != 3 || strings.Trim(code, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+		return "", newValidationError(field, fmt.Sprintf("%s must be a three-letter currency code, got %q", field, code))
+	}
+	return code, nil
+}
+
 // ============================================
 // REFUND METHODS
 // ============================================
 
-// CreateRefund requests a refund
+// RefundReason is the reason category of a refund
+type RefundReason string
+
+// Refund reasons accepted by the API
+const (
+	RefundReasonDuplicate           RefundReason = "duplicate"
+	RefundReasonFraudulent          RefundReason = "fraudulent"
+	RefundReasonRequestedByCustomer RefundReason = "requested_by_customer"
+)
+
+// validate checks the reason is one the API accepts; empty is allowed
+func (r RefundReason) validate() error {
+	switch r {
+	case "", RefundReasonDuplicate, RefundReasonFraudulent, RefundReasonRequestedByCustomer:
+		return nil
+	}
+	return newValidationError("Reason", fmt.Sprintf("unknown refund reason %q; use a RefundReason constant and put free text in Note", string(r)))
+}
+
+// CreateRefund requests a refund. reason must be one of the RefundReason
+// values; use CreateRefundWithParams to attach a free-text note.
 func (c *Client) CreateRefund(transactionID string, amount float64, reason string) (map[string]interface{}, error) {
+	if err := RefundReason(reason).validate(); err != nil {
+		return nil, err
+	}
+	
 	data := url.Values{}
 	data.Set("transaction_id", transactionID)
 	if amount > 0 {
@@ -42,7 +164,119 @@ func (c *Client) CreateRefund(transactionID string, amount float64, reason strin
 	return result, err
 }
 
-// ApproveRefund approves a pending refund
+// Refund is a refund request and its current state
+type Refund struct {
+	ID            string  `json:"id"`
+	TransactionID string  `json:"transaction_id"`
+	Amount        float64 `json:"amount"`
+	Reason        string  `json:"reason,omitempty"`
+	Status        string  `json:"status"`
+	CreatedAt     string  `json:"created_at,omitempty"`
+
+	// Replayed is true when the server returned an existing refund for a
+	// repeated request rather than creating a new one
+	Replayed bool `json:"-"`
+}
+
+// RefundParams contains parameters for a refund
+type RefundParams struct {
+	TransactionID string
+	Amount        float64 // 0 refunds the full amount
+	Reason        RefundReason
+	Note          string // free-text explanation
+	
+	// OriginalAmount is the amount originally charged. When set, Amount is
+	// checked against it locally before any request is made.
+	OriginalAmount float64
+}
+
+// CreateRefundWithParams requests a refund, optionally validating the
+// amount against the original charge first
+func (c *Client) CreateRefundWithParams(ctx context.Context, params RefundParams) (*Refund, error) {
+	if params.TransactionID == "" {
+		return nil, newValidationError("TransactionID", "TransactionID is required")
+	}
+	if params.Amount < 0 {
+		return nil, newValidationError("Amount", "Amount must not be negative")
+	}
+	if err := params.Reason.validate(); err != nil {
+		return nil, err
+	}
+	if params.OriginalAmount > 0 && params.Amount > params.OriginalAmount {
+		return nil, newValidationError("Amount", fmt.Sprintf("refund amount %.2f exceeds original amount %.2f", params.Amount, params.OriginalAmount))
+	}
+	
+	data := url.Values{}
+	data.Set("transaction_id", params.TransactionID)
+	if params.Amount > 0 {
+		data.Set("amount", fmt.Sprintf("%f", params.Amount))
+	}
+	if params.Reason != "" {
+		data.Set("reason", string(params.Reason))
+	}
+	if params.Note != "" {
+		data.Set("note", params.Note)
+	}
+	
+	result := &Refund{}
+	resp, err := c.send(ctx, "POST", "/api/v1/refunds/request", data, result)
+	if resp != nil {
+		result.Replayed = resp.replayed()
+	}
+	return result, err
+}
+
+// GetRefundsByTransaction gets every refund issued against a transaction,
+// including multiple partial refunds
+func (c *Client) GetRefundsByTransaction(ctx context.Context, transactionID string) ([]Refund, error) {
+	if transactionID == "" {
+		return nil, newValidationError("transactionID", "transactionID is required")
+	}
+	
+	query := url.Values{}
+	query.Set("transaction_id", transactionID)
+	
+	var result []Refund
+	err := c.requestContext(ctx, "GET", "/api/v1/refunds?"+query.Encode(), nil, &result)
+	return result, err
+}
+
+// RefundEligibility reports whether a transaction can be refunded
+type RefundEligibility struct {
+	Eligible bool `json:"eligible"`
+
+	// MaxRefundable is the amount still refundable after any prior
+	// partial refunds
+	MaxRefundable float64 `json:"max_refundable"`
+	Currency      string  `json:"currency,omitempty"`
+
+	// Reason explains why the refund is blocked when Eligible is false
+	Reason string `json:"reason,omitempty"`
+}
+
+// CheckRefundEligibility checks whether amount can be refunded for a
+// transaction without creating a refund. Pass 0 to check a full refund.
+func (c *Client) CheckRefundEligibility(ctx context.Context, transactionID string, amount float64) (*RefundEligibility, error) {
+	if transactionID == "" {
+		return nil, newValidationError("transactionID", "transactionID is required")
+	}
+	if amount < 0 {
+		return nil, newValidationError("amount", "amount must not be negative")
+	}
+	
+	query := url.Values{}
+	query.Set("transaction_id", transactionID)
+	if amount > 0 {
+		query.Set("amount", fmt.Sprintf("%f", amount))
+	}
+	
+	result := &RefundEligibility{}
+	err := c.requestContext(ctx, "GET", "/api/v1/refunds/eligibility?"+query.Encode(), nil, result)
+	return result, err
+}
+
+// ApproveRefund approves a pending refund. If the refund was already
+// approved or rejected, the error matches ErrConflict via errors.Is.
 func (c *Client) ApproveRefund(refundID string) (map[string]interface{}, error) {
 	var result map[string]interface{}
 	err := c.request("POST", fmt.Sprintf("/api/v1/refunds/%s/approve", refundID), nil, &result)
@@ -53,7 +287,8 @@ func (c *Client) ApproveRefund(refundID string) (map[string]interface{}, error)
 // DISPUTE METHODS
 // ============================================
 
-// ListDisputes lists disputes
+// ListDisputes lists disputes in a single call. Use ListDisputesPage or
+// DisputeIterator for accounts with many disputes.
 func (c *Client) ListDisputes(status string) ([]map[string]interface{}, error) {
 	path := "/api/v1/disputes"
 	if status != "" {
@@ -65,6 +300,58 @@ func (c *Client) ListDisputes(status string) ([]map[string]interface{}, error) {
 	return result, err
 }
 
+// Dispute is a chargeback or payment dispute raised against a transaction
+type Dispute struct {
+	ID            string  `json:"id"`
+	TransactionID string  `json:"transaction_id"`
+	Amount        float64 `json:"amount"`
+	Reason        string  `json:"reason,omitempty"`
+	Status        string  `json:"status"`
+	CreatedAt     string  `json:"created_at,omitempty"`
+}
+
+// DisputePage is one page of disputes
+type DisputePage = Page[Dispute]
+
+// DisputeListParams narrows and pages through disputes
+type DisputeListParams struct {
+	Status string
+	Limit  int // page size; 0 uses the server default
+	Cursor string
+}
+
+// ListDisputesPage gets a single page of disputes
+func (c *Client) ListDisputesPage(ctx context.Context, params DisputeListParams) (*DisputePage, error) {
+	query := url.Values{}
+	if params.Status != "" {
+		query.Set("status", params.Status)
+	}
+	if params.Limit > 0 {
+		query.Set("limit", strconv.Itoa(params.Limit))
+	}
+	if params.Cursor != "" {
+		query.Set("cursor", params.Cursor)
+	}
+	
+	path := "/api/v1/disputes"
+	if encoded := query.Encode(); encoded != "" {
+		path += "?" + encoded
+	}
+	
+	result := &DisputePage{}
+	err := c.requestContext(ctx, "GET", path, nil, result)
+	return result, err
+}
+
+// DisputeIterator returns an iterator over all disputes matching params,
+// fetching pages lazily as the caller advances
+func (c *Client) DisputeIterator(ctx context.Context, params DisputeListParams) *Iterator[Dispute] {
+	return newIterator(ctx, c, params.Cursor, func(ctx context.Context, cursor string) (*Page[Dispute], error) {
+		params.Cursor = cursor
+		return c.ListDisputesPage(ctx, params)
+	})
+}
+
 // RespondToDispute responds to a dispute
 func (c *Client) RespondToDispute(disputeID, message string) (map[string]interface{}, error) {
 	data := url.Values{}
@@ -75,13 +362,114 @@ func (c *Client) RespondToDispute(disputeID, message string) (map[string]interfa
 	return result, err
 }
 
+// DisputeDocument is evidence attached to a dispute. Read the file from
+// it and Close it when done.
+type DisputeDocument struct {
+	io.ReadCloser
+	ContentType string
+	Filename    string // from Content-Disposition, if sent
+}
+
+// GetDisputeDocument downloads a document submitted as dispute evidence,
+// streaming the file rather than buffering it. The client timeout covers
+// the whole download, so use a Clone with a longer WithTimeout for large
+// files.
+func (c *Client) GetDisputeDocument(ctx context.Context, disputeID, documentID string) (*DisputeDocument, error) {
+	if disputeID == "" {
+		return nil, newValidationError("disputeID", "disputeID is required")
+	}
+	if documentID == "" {
+		return nil, newValidationError("documentID", "documentID is required")
+	}
+	
+	path := fmt.Sprintf("/api/v1/disputes/%s/documents/%s", url.PathEscape(disputeID), url.PathEscape(documentID))
+	resp, err := c.DoRaw(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, newAPIError(resp, body)
+	}
+	
+	doc := &DisputeDocument{
+		ReadCloser:  resp.Body,
+		ContentType: resp.Header.Get("Content-Type"),
+	}
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil {
+		doc.Filename = params["filename"]
+	}
+	return doc, nil
+}
+
 // ============================================
 // ANALYTICS METHODS
 // ============================================
 
-// GetAPIUsage gets usage stats
+// GetAPIUsage gets usage stats, scoped to one sub-merchant when the
+// client was created with WithOnBehalfOf
 func (c *Client) GetAPIUsage() (map[string]interface{}, error) {
 	var result map[string]interface{}
 	err := c.request("GET", "/api/v1/analytics/api-usage", nil, &result)
 	return result, err
 }
+
+// ============================================
+// WEBHOOK METHODS
+// ============================================
+
+// Webhook is a webhook endpoint registered on the account
+type Webhook struct {
+	ID        string   `json:"id"`
+	URL       string   `json:"url"`
+	Events    []string `json:"events"`
+	Secret    string   `json:"secret,omitempty"` // signing secret
+	CreatedAt string   `json:"created_at,omitempty"`
+}
+
+// ListWebhooks lists webhook endpoints
+func (c *Client) ListWebhooks(ctx context.Context) ([]Webhook, error) {
+	var result []Webhook
+	err := c.requestContext(ctx, "GET", "/api/v1/webhooks/", nil, &result)
+	return result, err
+}
+
+// CreateWebhook registers a webhook endpoint for the given event types
+func (c *Client) CreateWebhook(ctx context.Context, webhookURL string, events []string) (*Webhook, error) {
+	if webhookURL == "" {
+		return nil, newValidationError("url", "url is required")
+	}
+	
+	data := url.Values{}
+	data.Set("url", webhookURL)
+	for _, event := range events {
+		data.Add("events", event)
+	}
+	
+	result := &Webhook{}
+	err := c.requestContext(ctx, "POST", "/api/v1/webhooks/", data, result)
+	return result, err
+}
+
+// DeleteWebhook deletes a webhook endpoint
+func (c *Client) DeleteWebhook(ctx context.Context, webhookID string) error {
+	if webhookID == "" {
+		return newValidationError("webhookID", "webhookID is required")
+	}
+	
+	var result map[string]interface{}
+	return c.requestContext(ctx, "DELETE", fmt.Sprintf("/api/v1/webhooks/%s", url.PathEscape(webhookID)), nil, &result)
+}
+
+// RotateWebhookSecret issues a new signing secret for a webhook endpoint.
+// Verify with both the old and new secret until deliveries switch over.
+func (c *Client) RotateWebhookSecret(ctx context.Context, webhookID string) (*Webhook, error) {
+	if webhookID == "" {
+		return nil, newValidationError("webhookID", "webhookID is required")
+	}
+	
+	result := &Webhook{}
+	err := c.requestContext(ctx, "POST", fmt.Sprintf("/api/v1/webhooks/%s/rotate-secret", url.PathEscape(webhookID)), nil, result)
+	return result, err
+}
@@ -0,0 +1,53 @@
+package shegerpay
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestWebhookIDValidatedAndEscaped(t *testing.T) {
+	var paths []string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.EscapedPath())
+		writeJSON(w, http.StatusOK, `{}`)
+	})
+	ctx := context.Background()
+
+	var validationErr *ValidationError
+	if err := client.DeleteWebhook(ctx, ""); !errors.As(err, &validationErr) {
+		t.Errorf("DeleteWebhook(\"\") = %v, want *ValidationError", err)
+	}
+	if _, err := client.RotateWebhookSecret(ctx, ""); !errors.As(err, &validationErr) {
+		t.Errorf("RotateWebhookSecret(\"\") = %v, want *ValidationError", err)
+	}
+
+	if err := client.DeleteWebhook(ctx, "wh/1?x"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.RotateWebhookSecret(ctx, "wh/1?x"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"/api/v1/webhooks/wh%2F1%3Fx", "/api/v1/webhooks/wh%2F1%3Fx/rotate-secret"}
+	if len(paths) != len(want) || paths[0] != want[0] || paths[1] != want[1] {
+		t.Errorf("requested %q, want %q", paths, want)
+	}
+}
+
+func TestApproveRefundNoContent(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v1/refunds/rf_1/approve" {
+			t.Errorf("got %s %s", r.Method, r.URL.Path)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	result, err := client.ApproveRefund("rf_1")
+	if err != nil {
+		t.Fatalf("ApproveRefund on 204: %v", err)
+	}
+	if len(result) != 0 {
+		t.Errorf("result = %v, want empty", result)
+	}
+}
@@ -0,0 +1,46 @@
+// Package shegerpaytest provides helpers for testing code that integrates
+// with the ShegerPay Go SDK.
+package shegerpaytest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	shegerpay "github.com/black12-ag/shegerpay-sdk/go"
+)
+
+// GenerateWebhookPayload builds a webhook event of the given type carrying
+// data, and signs it with secret using the current time. The returned
+// header is the value ShegerPay sends in shegerpay.WebhookSignatureHeader.
+//
+// Usage:
+//
+//	payload, header := shegerpaytest.GenerateWebhookPayload("payment.verified", data, secret)
+//	req := httptest.NewRequest("POST", "/webhooks", bytes.NewReader(payload))
+//	req.Header.Set(shegerpay.WebhookSignatureHeader, header)
+func GenerateWebhookPayload(eventType string, data interface{}, secret string) (payload []byte, signatureHeader string) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		panic("shegerpaytest: cannot marshal webhook data: " + err.Error())
+	}
+
+	now := time.Now()
+	payload, err = json.Marshal(shegerpay.WebhookEvent{
+		ID:      "evt_test_" + randomHex(8),
+		Type:    eventType,
+		Created: now.Unix(),
+		Data:    raw,
+	})
+	if err != nil {
+		panic("shegerpaytest: cannot marshal webhook event: " + err.Error())
+	}
+	return payload, shegerpay.SignWebhookPayload(string(payload), secret, now)
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
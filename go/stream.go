@@ -0,0 +1,143 @@
+package shegerpay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// StreamHistory calls fn for every transaction matching filter, decoding
+// each page incrementally as it arrives instead of buffering it, so memory
+// stays flat however large filter.Limit is. The client timeout covers the
+// download of each page. Iteration stops at the first error returned by
+// fn, which StreamHistory returns.
+func (c *Client) StreamHistory(ctx context.Context, filter HistoryFilter, fn func(Transaction) error) error {
+	for {
+		page, err := c.streamHistoryPage(ctx, filter, fn)
+		if err != nil {
+			return err
+		}
+		if !page.HasMore || page.NextCursor == "" {
+			return nil
+		}
+		filter.Cursor = page.NextCursor
+	}
+}
+
+// streamHistoryPage streams one history page to fn, retrying when the API
+// rate-limits the request (see rateLimitDelay). Rate limiting is reported
+// before any data is sent, so no item is delivered twice. The returned
+// page has no Data.
+func (c *Client) streamHistoryPage(ctx context.Context, filter HistoryFilter, fn func(Transaction) error) (*HistoryPage, error) {
+	path := "/api/v1/transactions/history"
+	if query := filter.values().Encode(); query != "" {
+		path += "?" + query
+	}
+
+	for attempt := 0; ; attempt++ {
+		// getStream bypasses send, so credit the retry budget here; it
+		// does not retry, whatever the request policy
+		c.retryBudget.deposit()
+		body, err := c.getStream(ctx, path)
+		if wait, retry := c.rateLimitDelay(RequestPolicy{}, attempt, err); retry {
+			if err := sleepContext(ctx, wait); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		page, err := decodePageStream(body, fn)
+		body.Close()
+		return page, err
+	}
+}
+
+// getStream performs a GET and returns the response body for incremental
+// decoding. Error statuses are returned as *APIError.
+func (c *Client) getStream(ctx context.Context, path string) (io.ReadCloser, error) {
+	resp, err := c.DoRaw(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, newAPIError(resp, body)
+	}
+	return resp.Body, nil
+}
+
+// decodePageStream decodes a Page object token by token, passing each
+// element of its data array to fn as soon as it is decoded
+func decodePageStream[T any](r io.Reader, fn func(T) error) (*Page[T], error) {
+	decoder := json.NewDecoder(r)
+	if err := expectDelim(decoder, '{'); err != nil {
+		return nil, err
+	}
+
+	page := &Page[T]{}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch token {
+		case "data":
+			err = decodeArrayStream(decoder, fn)
+		case "next_cursor":
+			err = decoder.Decode(&page.NextCursor)
+		case "has_more":
+			err = decoder.Decode(&page.HasMore)
+		default:
+			var skip json.RawMessage
+			err = decoder.Decode(&skip)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return page, expectDelim(decoder, '}')
+}
+
+// decodeArrayStream decodes a JSON array element by element, calling fn
+// for each. A null array is treated as empty.
+func decodeArrayStream[T any](decoder *json.Decoder, fn func(T) error) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token == nil {
+		return nil
+	}
+	if token != json.Delim('[') {
+		return fmt.Errorf("expected JSON array, got %v", token)
+	}
+
+	for decoder.More() {
+		var item T
+		if err := decoder.Decode(&item); err != nil {
+			return err
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	return expectDelim(decoder, ']')
+}
+
+// expectDelim consumes the next token and checks it is delim
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("expected %v in JSON response, got %v", delim, token)
+	}
+	return nil
+}
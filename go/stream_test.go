@@ -0,0 +1,97 @@
+package shegerpay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStreamHistoryThousandsOfRecords(t *testing.T) {
+	const pages, perPage = 10, 500
+	var calls atomic.Int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		page, _ := strconv.Atoi(r.URL.Query().Get("cursor"))
+
+		var body strings.Builder
+		body.WriteString(`{"data":[`)
+		for i := 0; i < perPage; i++ {
+			if i > 0 {
+				body.WriteByte(',')
+			}
+			fmt.Fprintf(&body, `{"id":"%d","transaction_id":"FT%d","provider":"cbe","status":"verified","valid":true,"amount":%d}`, page*perPage+i, page*perPage+i, i)
+		}
+		body.WriteString(`]`)
+		if page+1 < pages {
+			fmt.Fprintf(&body, `,"next_cursor":"%d","has_more":true`, page+1)
+		}
+		body.WriteString(`}`)
+		writeJSON(w, http.StatusOK, body.String())
+	})
+
+	next := 0
+	err := client.StreamHistory(context.Background(), HistoryFilter{}, func(tx Transaction) error {
+		if tx.ID != strconv.Itoa(next) {
+			return fmt.Errorf("got transaction %s, want %d", tx.ID, next)
+		}
+		next++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next != pages*perPage {
+		t.Errorf("streamed %d transactions, want %d", next, pages*perPage)
+	}
+	if got := calls.Load(); got != pages+1 {
+		t.Errorf("made %d requests, want %d", got, pages+1)
+	}
+}
+
+func TestRateLimitRetriesNotStacked(t *testing.T) {
+	var calls atomic.Int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusTooManyRequests)
+	}, WithRetry(2), WithBackoff(func(int) time.Duration { return 0 }))
+
+	it := client.HistoryIterator(context.Background(), HistoryFilter{})
+	if it.Next() {
+		t.Fatal("Next succeeded against a rate-limited API")
+	}
+	var apiErr *APIError
+	if !errors.As(it.Err(), &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("Err = %v, want a 429 APIError", it.Err())
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("made %d requests, want 3 (send's own retries only)", got)
+	}
+}
+
+func TestRateLimitRetriesSpendBudget(t *testing.T) {
+	var calls atomic.Int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}, WithRetryBudget(0, 1))
+
+	err := client.StreamHistory(context.Background(), HistoryFilter{}, func(Transaction) error { return nil })
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("got %v, want a 429 APIError", err)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("made %d requests, want 2 (one budgeted retry)", got)
+	}
+}
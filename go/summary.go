@@ -0,0 +1,82 @@
+package shegerpay
+
+import "context"
+
+// Totals counts transactions and sums their amounts per currency, so
+// amounts in different currencies are never added together
+type Totals struct {
+	Count   int
+	Amounts map[string]float64 // keyed by Transaction.Currency
+}
+
+func (t *Totals) add(tx Transaction) {
+	if t.Amounts == nil {
+		t.Amounts = map[string]float64{}
+	}
+	t.Count++
+	t.Amounts[tx.Currency] += tx.Amount
+}
+
+// Summary aggregates transaction history
+type Summary struct {
+	Total      Totals
+	ByProvider map[string]*Totals
+	ByStatus   map[string]*Totals
+}
+
+// SummarizeHistory computes totals by provider and status, each split by
+// currency. For example, the verified ETB total for CBE is
+//
+//	summary.ByProvider["cbe"].Amounts["ETB"]
+//
+// when items were fetched with Status "verified".
+func SummarizeHistory(items []Transaction) Summary {
+	summary := newSummary()
+	for _, tx := range items {
+		summary.add(tx)
+	}
+	return summary
+}
+
+func newSummary() Summary {
+	return Summary{
+		ByProvider: map[string]*Totals{},
+		ByStatus:   map[string]*Totals{},
+	}
+}
+
+func (s *Summary) add(tx Transaction) {
+	s.Total.add(tx)
+	totalsFor(s.ByProvider, tx.Provider).add(tx)
+	totalsFor(s.ByStatus, tx.Status).add(tx)
+}
+
+// totalsFor returns the totals for key, creating them on first use
+func totalsFor(groups map[string]*Totals, key string) *Totals {
+	totals, ok := groups[key]
+	if !ok {
+		totals = &Totals{}
+		groups[key] = totals
+	}
+	return totals
+}
+
+// GetHistorySummary summarizes all transaction history matching filter,
+// walking every page without holding it in memory, e.g. today's verified
+// payments:
+//
+//	summary, err := client.GetHistorySummary(ctx, shegerpay.HistoryFilter{
+//	    Status: shegerpay.StatusVerified,
+//	    From:   midnight,
+//	})
+func (c *Client) GetHistorySummary(ctx context.Context, filter HistoryFilter) (*Summary, error) {
+	summary := newSummary()
+	it := c.HistoryIterator(ctx, filter)
+	for it.Next() {
+		summary.add(it.Item())
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
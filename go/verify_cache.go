@@ -0,0 +1,112 @@
+package shegerpay
+
+import (
+	"maps"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+)
+
+// WithVerifyCache remembers final verification results (see IsFinal) for
+// ttl, keyed by every VerifyParams field and the account the client acts
+// for, so double-submits and retries within that window skip the API.
+// Pending results and errors are never cached, nor are results for
+// clients using WithBearerToken. Cached results have Cached set.
+func WithVerifyCache(ttl time.Duration) ClientOption {
+	return func(c *Client) {
+		c.verifyCache = &verifyCache{ttl: ttl, entries: map[string]verifyCacheEntry{}}
+	}
+}
+
+// verifyCache is the short-lived result cache installed by
+// WithVerifyCache. A nil *verifyCache caches nothing.
+type verifyCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]verifyCacheEntry
+}
+
+type verifyCacheEntry struct {
+	result  VerificationResult
+	expires time.Time
+}
+
+// verifyCacheKey keys a verification by the request form, which carries
+// every field of VerifyParams, and the account it is made for. It reports
+// false if the result must not be cached.
+func (c *Client) verifyCacheKey(data url.Values) (string, bool) {
+	if c.verifyCache == nil {
+		return "", false
+	}
+	scope, ok := c.accountScope()
+	if !ok {
+		return "", false
+	}
+	return scope + " " + data.Encode(), true
+}
+
+// get returns a copy of the cached result for key, if still fresh
+func (vc *verifyCache) get(key string) (*VerificationResult, bool) {
+	if vc == nil {
+		return nil, false
+	}
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+
+	entry, ok := vc.entries[key]
+	if !ok || time.Now().After(entry.expires) {
+		return nil, false
+	}
+	result := cloneResult(entry.result)
+	result.Cached = true
+	return &result, true
+}
+
+// set caches result if it is final, dropping expired entries on the way
+func (vc *verifyCache) set(key string, result *VerificationResult) {
+	if vc == nil || !result.IsFinal() {
+		return
+	}
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+
+	now := time.Now()
+	for stale, entry := range vc.entries {
+		if now.After(entry.expires) {
+			delete(vc.entries, stale)
+		}
+	}
+	vc.entries[key] = verifyCacheEntry{result: cloneResult(*result), expires: now.Add(vc.ttl)}
+}
+
+// cloneResult deep-copies the maps and raw body of r, so a cached result
+// is never shared with a caller that may modify it
+func cloneResult(r VerificationResult) VerificationResult {
+	r.Metadata = maps.Clone(r.Metadata)
+	if r.Receipt != nil {
+		r.Receipt = cloneJSONValue(r.Receipt).(map[string]interface{})
+	}
+	r.RawJSON = slices.Clone(r.RawJSON)
+	return r
+}
+
+// cloneJSONValue deep-copies a value decoded from JSON into interface{}
+func cloneJSONValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		clone := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			clone[key] = cloneJSONValue(value)
+		}
+		return clone
+	case []interface{}:
+		clone := make([]interface{}, len(v))
+		for i, value := range v {
+			clone[i] = cloneJSONValue(value)
+		}
+		return clone
+	}
+	return v
+}
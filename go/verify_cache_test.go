@@ -0,0 +1,126 @@
+package shegerpay
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestVerifyCacheKeyCoversParams(t *testing.T) {
+	var calls atomic.Int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		r.ParseForm()
+		if r.PostForm.Get("amount_tolerance") != "" {
+			writeJSON(w, http.StatusOK, `{"valid":true,"status":"verified"}`)
+			return
+		}
+		writeJSON(w, http.StatusOK, `{"valid":false,"status":"failed","reason":"amount mismatch"}`)
+	}, WithVerifyCache(time.Minute))
+
+	params := VerifyParams{Provider: ProviderCBE, TransactionID: "FT123", Amount: 100}
+	if result, err := client.VerifyContext(context.Background(), params); err != nil || result.Valid {
+		t.Fatalf("first verify = %+v, %v; want failed result", result, err)
+	}
+
+	params.AmountTolerance = 0.5
+	result, err := client.VerifyContext(context.Background(), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Valid || result.Cached {
+		t.Errorf("retry with AmountTolerance = %+v; want fresh verified result", result)
+	}
+
+	result, err = client.VerifyContext(context.Background(), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Cached {
+		t.Error("identical retry was not served from the cache")
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("API called %d times, want 2", got)
+	}
+}
+
+func TestVerifyCacheScopedToTenant(t *testing.T) {
+	var calls atomic.Int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		writeJSON(w, http.StatusOK, `{"valid":true,"status":"verified","metadata":{"tenant":"`+r.Header.Get("On-Behalf-Of")+`"}}`)
+	}, WithVerifyCache(time.Minute))
+
+	params := VerifyParams{Provider: ProviderCBE, TransactionID: "FT123", Amount: 100}
+	if _, err := client.Clone(WithOnBehalfOf("A")).VerifyContext(context.Background(), params); err != nil {
+		t.Fatal(err)
+	}
+	result, err := client.Clone(WithOnBehalfOf("B")).VerifyContext(context.Background(), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Cached || result.Metadata["tenant"] != "B" {
+		t.Errorf("tenant B got %+v; want its own result", result)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("API called %d times, want 2", got)
+	}
+}
+
+func TestVerifyCacheSkipsAccepted(t *testing.T) {
+	var calls atomic.Int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusAccepted)
+	}, WithVerifyCache(time.Minute))
+
+	params := VerifyParams{Provider: ProviderCBE, TransactionID: "FT123", Amount: 100}
+	for i := 0; i < 2; i++ {
+		result, err := client.VerifyContext(context.Background(), params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.IsFinal() || result.Cached {
+			t.Fatalf("202 result = %+v; want pending, uncached", result)
+		}
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("API called %d times, want 2", got)
+	}
+}
+
+func TestVerifyCacheResultsNotShared(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, `{"valid":true,"status":"verified","metadata":{"order":"42"},"receipt":{"lines":[{"ref":"A"}]}}`)
+	}, WithVerifyCache(time.Minute))
+
+	params := VerifyParams{Provider: ProviderCBE, TransactionID: "FT123", Amount: 100}
+	for i := 0; i < 2; i++ {
+		result, err := client.VerifyContext(context.Background(), params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result.Metadata["order"] = "tampered"
+		result.Receipt["lines"].([]interface{})[0].(map[string]interface{})["ref"] = "tampered"
+		result.RawJSON[0] = 'X'
+	}
+
+	result, err := client.VerifyContext(context.Background(), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Cached {
+		t.Fatal("result was not served from the cache")
+	}
+	if got := result.Metadata["order"]; got != "42" {
+		t.Errorf("cached Metadata[order] = %q, want 42", got)
+	}
+	if got := result.Receipt["lines"].([]interface{})[0].(map[string]interface{})["ref"]; got != "A" {
+		t.Errorf("cached receipt ref = %v, want A", got)
+	}
+	if result.RawJSON[0] != '{' {
+		t.Errorf("cached RawJSON = %s, want the original body", result.RawJSON)
+	}
+}
@@ -0,0 +1,152 @@
+package shegerpay
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultWebhookTolerance is the recommended maximum clock difference
+// between a webhook's signing timestamp and the time it is verified
+const DefaultWebhookTolerance = 5 * time.Minute
+
+// Webhook verification errors
+var (
+	ErrInvalidSignatureHeader    = errors.New("invalid webhook signature header")
+	ErrSignatureMismatch         = errors.New("webhook signature does not match")
+	ErrTimestampOutsideTolerance = errors.New("webhook timestamp outside tolerance")
+	ErrUnknownWebhookKey         = errors.New("unknown webhook signing key")
+)
+
+// SignatureHeader is a parsed "t=<timestamp>,v1=<signature>" webhook header
+type SignatureHeader struct {
+	// Timestamp is the Unix time at which the webhook was signed
+	Timestamp int64
+
+	// Signatures holds every v1 signature in the header. Several are sent
+	// while a webhook secret is being rotated.
+	Signatures []string
+}
+
+// ParseSignatureHeader parses the raw webhook signature header value,
+// e.g. "t=1700000000,v1=5257a869...,v1=6ffbb59b...". Unknown keys are ignored.
+func ParseSignatureHeader(header string) (*SignatureHeader, error) {
+	parsed := &SignatureHeader{}
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "t":
+			timestamp, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, ErrInvalidSignatureHeader
+			}
+			parsed.Timestamp = timestamp
+		case "v1":
+			parsed.Signatures = append(parsed.Signatures, value)
+		}
+	}
+
+	if parsed.Timestamp == 0 || len(parsed.Signatures) == 0 {
+		return nil, ErrInvalidSignatureHeader
+	}
+	return parsed, nil
+}
+
+// VerifyWebhookSignatureHeader verifies a webhook against the raw signature
+// header value (not a pre-split signature). Each v1 signature is checked
+// against the HMAC-SHA256 of "timestamp.payload"; one match is enough.
+func VerifyWebhookSignatureHeader(payload, header, secret string) error {
+	parsed, err := ParseSignatureHeader(header)
+	if err != nil {
+		return err
+	}
+	if !parsed.matches(payload, secret) {
+		return ErrSignatureMismatch
+	}
+	return nil
+}
+
+// matches reports whether any signature in the header was made with secret
+func (h *SignatureHeader) matches(payload, secret string) bool {
+	expected := []byte(computeWebhookSignature(h.Timestamp, payload, secret))
+	for _, signature := range h.Signatures {
+		if hmac.Equal(expected, []byte(signature)) {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookVerifier verifies webhooks for one endpoint. It groups the
+// signing secrets and timestamp tolerance so the configuration can be
+// injected, e.g. one verifier per merchant.
+type WebhookVerifier struct {
+	secrets   []string
+	tolerance time.Duration // maximum age of a timestamp
+	skew      time.Duration // maximum distance of a timestamp in the future
+}
+
+// NewWebhookVerifier creates a verifier that accepts signatures made with
+// any of secrets (pass both while rotating). Timestamps older than
+// tolerance are rejected, as are timestamps more than tolerance in the
+// future, which covers a sender whose clock runs ahead; use
+// WithClockSkew to set the future bound separately. A tolerance of 0
+// disables timestamp checks entirely.
+func NewWebhookVerifier(tolerance time.Duration, secrets ...string) *WebhookVerifier {
+	return &WebhookVerifier{secrets: secrets, tolerance: tolerance, skew: tolerance}
+}
+
+// WithClockSkew sets how far in the future a webhook timestamp may be
+// before it is rejected, independently of the maximum age. It returns v
+// for chaining.
+func (v *WebhookVerifier) WithClockSkew(skew time.Duration) *WebhookVerifier {
+	v.skew = skew
+	return v
+}
+
+// Verify checks the raw signature header value against payload. The
+// timestamp must lie within [now-tolerance, now+skew].
+func (v *WebhookVerifier) Verify(payload, header string) error {
+	parsed, err := ParseSignatureHeader(header)
+	if err != nil {
+		return err
+	}
+
+	if v.tolerance > 0 {
+		// Compare in seconds: a Duration from an arbitrary timestamp
+		// could overflow
+		now := time.Now().Unix()
+		if parsed.Timestamp < now-int64(v.tolerance/time.Second) || parsed.Timestamp > now+int64(v.skew/time.Second) {
+			return ErrTimestampOutsideTolerance
+		}
+	}
+
+	for _, secret := range v.secrets {
+		if parsed.matches(payload, secret) {
+			return nil
+		}
+	}
+	return ErrSignatureMismatch
+}
+
+// SignWebhookPayload returns the signature header value ShegerPay would
+// send for payload, in the format accepted by VerifyWebhookSignatureHeader
+// and WebhookVerifier. It is mainly useful for tests.
+func SignWebhookPayload(payload, secret string, timestamp time.Time) string {
+	unix := timestamp.Unix()
+	return "t=" + strconv.FormatInt(unix, 10) + ",v1=" + computeWebhookSignature(unix, payload, secret)
+}
+
+// computeWebhookSignature returns the hex HMAC-SHA256 of "timestamp.payload"
+func computeWebhookSignature(timestamp int64, payload, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "." + payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,219 @@
+package shegerpay
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// WebhookSignatureHeader is the request header carrying the webhook signature
+const WebhookSignatureHeader = "X-ShegerPay-Signature"
+
+// maxWebhookBodySize caps the webhook request body read by WebhookHandler
+const maxWebhookBodySize = 1 << 20
+
+// WebhookEvent is a webhook notification sent by ShegerPay
+type WebhookEvent struct {
+	ID      string          `json:"id"`
+	Type    string          `json:"type"`
+	Created int64           `json:"created"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// Webhook event types with typed payloads, see the As* methods of
+// WebhookEvent
+const (
+	EventPaymentVerified = "payment.verified"
+	EventRefundApproved  = "refund.approved"
+	EventDisputeCreated  = "dispute.created"
+)
+
+// ErrEventTypeMismatch is returned by the As* methods of WebhookEvent when
+// the event is of a different type
+var ErrEventTypeMismatch = errors.New("webhook event type mismatch")
+
+// PaymentVerifiedEvent is the payload of a payment.verified event
+type PaymentVerifiedEvent struct {
+	VerificationResult
+}
+
+// RefundApprovedEvent is the payload of a refund.approved event
+type RefundApprovedEvent struct {
+	RefundID      string    `json:"refund_id"`
+	TransactionID string    `json:"transaction_id"`
+	Amount        float64   `json:"amount"`
+	Currency      string    `json:"currency,omitempty"`
+	ApprovedBy    string    `json:"approved_by,omitempty"`
+	ApprovedAt    time.Time `json:"approved_at"`
+}
+
+// DisputeCreatedEvent is the payload of a dispute.created event
+type DisputeCreatedEvent struct {
+	DisputeID     string  `json:"dispute_id"`
+	TransactionID string  `json:"transaction_id"`
+	Amount        float64 `json:"amount,omitempty"`
+	Reason        string  `json:"reason"`
+
+	// Deadline is when the dispute must be answered with RespondToDispute
+	Deadline time.Time `json:"deadline"`
+}
+
+// AsPaymentVerified decodes the payload of a payment.verified event
+func (e *WebhookEvent) AsPaymentVerified() (*PaymentVerifiedEvent, error) {
+	data := &PaymentVerifiedEvent{}
+	if err := e.decodeData(EventPaymentVerified, data); err != nil {
+		return nil, err
+	}
+	data.RawJSON = e.Data
+	return data, nil
+}
+
+// AsRefundApproved decodes the payload of a refund.approved event
+func (e *WebhookEvent) AsRefundApproved() (*RefundApprovedEvent, error) {
+	data := &RefundApprovedEvent{}
+	if err := e.decodeData(EventRefundApproved, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// AsDisputeCreated decodes the payload of a dispute.created event
+func (e *WebhookEvent) AsDisputeCreated() (*DisputeCreatedEvent, error) {
+	data := &DisputeCreatedEvent{}
+	if err := e.decodeData(EventDisputeCreated, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// decodeData decodes the event data into v after checking the event type
+func (e *WebhookEvent) decodeData(eventType string, v interface{}) error {
+	if e.Type != eventType {
+		return fmt.Errorf("%w: got %q, want %q", ErrEventTypeMismatch, e.Type, eventType)
+	}
+	if err := json.Unmarshal(e.Data, v); err != nil {
+		return fmt.Errorf("decode %s event: %w", eventType, err)
+	}
+	return nil
+}
+
+// ParseWebhookEvent decodes a webhook payload. Verify its signature first.
+func ParseWebhookEvent(payload []byte) (*WebhookEvent, error) {
+	event := &WebhookEvent{}
+	if err := json.Unmarshal(payload, event); err != nil {
+		return nil, err
+	}
+	if event.Type == "" {
+		return nil, errors.New("webhook event has no type")
+	}
+	return event, nil
+}
+
+// WebhookRouter verifies webhook events and dispatches them to handlers
+// registered per event type. The zero value is ready to use.
+//
+// Usage:
+//
+//	router := &shegerpay.WebhookRouter{}
+//	router.On(shegerpay.EventPaymentVerified, func(e *shegerpay.WebhookEvent) error {
+//	    payment, err := e.AsPaymentVerified()
+//	    ...
+//	})
+//	err := router.Handle(payload, signatureHeader, secret)
+type WebhookRouter struct {
+	handlers map[string]func(*WebhookEvent) error
+	fallback func(*WebhookEvent) error
+}
+
+// On registers the handler for an event type, replacing any previous one
+func (r *WebhookRouter) On(eventType string, handler func(*WebhookEvent) error) {
+	if r.handlers == nil {
+		r.handlers = make(map[string]func(*WebhookEvent) error)
+	}
+	r.handlers[eventType] = handler
+}
+
+// Default registers the handler for event types without their own handler.
+// Without one, unhandled events are ignored.
+func (r *WebhookRouter) Default(handler func(*WebhookEvent) error) {
+	r.fallback = handler
+}
+
+// Handle verifies the raw signature header, parses the payload and
+// dispatches the event. Timestamps are checked against
+// DefaultWebhookTolerance; use a WebhookVerifier for other settings.
+func (r *WebhookRouter) Handle(payload, signature, secret string) error {
+	if err := NewWebhookVerifier(DefaultWebhookTolerance, secret).Verify(payload, signature); err != nil {
+		return err
+	}
+
+	event, err := ParseWebhookEvent([]byte(payload))
+	if err != nil {
+		return err
+	}
+	return r.Dispatch(event)
+}
+
+// Dispatch routes an already verified event to its handler
+func (r *WebhookRouter) Dispatch(event *WebhookEvent) error {
+	if handler, ok := r.handlers[event.Type]; ok {
+		return handler(event)
+	}
+	if r.fallback != nil {
+		return r.fallback(event)
+	}
+	return nil
+}
+
+// WebhookHandler is an http.Handler that terminates webhooks end-to-end:
+// it reads the body, verifies the signature and timestamp, parses the
+// event and dispatches it. It replies 400 for bad requests or signatures,
+// 500 if the handler fails (so delivery is retried), and 200 on success.
+type WebhookHandler struct {
+	Verifier *WebhookVerifier
+	Router   *WebhookRouter
+}
+
+// Handler returns an http.Handler that verifies webhooks with v and
+// dispatches them through router.
+//
+// Usage:
+//
+//	mux.Handle("/webhooks/shegerpay", verifier.Handler(router))
+func (v *WebhookVerifier) Handler(router *WebhookRouter) *WebhookHandler {
+	return &WebhookHandler{Verifier: v, Router: router}
+}
+
+func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBodySize))
+	if err != nil {
+		http.Error(w, "could not read body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Verifier.Verify(string(payload), r.Header.Get(WebhookSignatureHeader)); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	event, err := ParseWebhookEvent(payload)
+	if err != nil {
+		http.Error(w, "invalid event payload", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Router.Dispatch(event); err != nil {
+		http.Error(w, "event handler failed", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
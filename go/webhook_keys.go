@@ -0,0 +1,146 @@
+package shegerpay
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"sync"
+	"time"
+)
+
+// webhookKeyCacheTTL is how long fetched webhook public keys are reused
+const webhookKeyCacheTTL = time.Hour
+
+// webhookKeyRefreshInterval is the minimum time between JWKS refreshes, so
+// webhooks naming unknown key IDs cannot make every call hit the API
+const webhookKeyRefreshInterval = time.Minute
+
+// VerifyWebhookSignatureEd25519 verifies an asymmetric webhook signature.
+// signature is the base64-encoded Ed25519 signature of payload.
+func VerifyWebhookSignatureEd25519(payload, signature string, publicKey ed25519.PublicKey) bool {
+	if len(publicKey) != ed25519.PublicKeySize {
+		return false
+	}
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		if sig, err = base64.RawURLEncoding.DecodeString(signature); err != nil {
+			return false
+		}
+	}
+	return ed25519.Verify(publicKey, []byte(payload), sig)
+}
+
+// jsonWebKey is an Ed25519 key in JWKS (RFC 8037) form
+type jsonWebKey struct {
+	KeyID string `json:"kid"`
+	Type  string `json:"kty"`
+	Curve string `json:"crv"`
+	X     string `json:"x"`
+}
+
+// webhookKeyCache holds the webhook signing keys fetched from the API
+type webhookKeyCache struct {
+	mu         sync.Mutex
+	keys       map[string]ed25519.PublicKey
+	fetched    time.Time
+	attempted  time.Time     // start of the last refresh
+	err        error         // outcome of the last refresh
+	refreshing chan struct{} // closed when the refresh in flight ends
+}
+
+// GetWebhookPublicKeys fetches the current webhook signing keys from the
+// JWKS endpoint, indexed by key ID
+func (c *Client) GetWebhookPublicKeys(ctx context.Context) (map[string]ed25519.PublicKey, error) {
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := c.requestContext(ctx, "GET", "/api/v1/webhooks/jwks", nil, &jwks); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]ed25519.PublicKey, len(jwks.Keys))
+	for _, key := range jwks.Keys {
+		if key.Type != "OKP" || key.Curve != "Ed25519" {
+			continue
+		}
+		x, err := base64.RawURLEncoding.DecodeString(key.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			continue
+		}
+		keys[key.KeyID] = ed25519.PublicKey(x)
+	}
+	return keys, nil
+}
+
+// VerifyWebhookWithPublicKey verifies an Ed25519-signed webhook using the
+// key identified by keyID. Keys are fetched once and cached for an hour;
+// an unknown key ID triggers a refresh to pick up rotated keys, at most
+// once a minute.
+func (c *Client) VerifyWebhookWithPublicKey(ctx context.Context, payload, signature, keyID string) error {
+	key, err := c.webhookPublicKey(ctx, keyID)
+	if err != nil {
+		return err
+	}
+	if !VerifyWebhookSignatureEd25519(payload, signature, key) {
+		return ErrSignatureMismatch
+	}
+	return nil
+}
+
+// webhookPublicKey looks up a cached webhook key, refreshing the cache
+// when it is stale or does not contain keyID. Only one refresh runs at a
+// time, without holding the lock, and refreshes are at least
+// webhookKeyRefreshInterval apart; in between, a stale key is still used.
+func (c *Client) webhookPublicKey(ctx context.Context, keyID string) (ed25519.PublicKey, error) {
+	cache := c.webhookKeys
+	for {
+		cache.mu.Lock()
+		key, ok := cache.keys[keyID]
+		if ok && time.Since(cache.fetched) < webhookKeyCacheTTL {
+			cache.mu.Unlock()
+			return key, nil
+		}
+		if done := cache.refreshing; done != nil {
+			cache.mu.Unlock()
+			select {
+			case <-done:
+				continue
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		if time.Since(cache.attempted) < webhookKeyRefreshInterval {
+			err := cache.err
+			cache.mu.Unlock()
+			switch {
+			case ok:
+				return key, nil
+			case err != nil:
+				return nil, err
+			}
+			return nil, ErrUnknownWebhookKey
+		}
+
+		done, previous := make(chan struct{}), cache.attempted
+		cache.refreshing, cache.attempted = done, time.Now()
+		cache.mu.Unlock()
+
+		keys, err := c.GetWebhookPublicKeys(ctx)
+
+		cache.mu.Lock()
+		switch {
+		case err == nil:
+			cache.keys, cache.fetched = keys, time.Now()
+		case ctx.Err() != nil:
+			// Abandoned by this caller, so let the next one try at once
+			cache.attempted = previous
+		}
+		cache.err = err
+		cache.refreshing = nil
+		close(done)
+		cache.mu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package shegerpay
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestWebhookKeyRefreshRateLimited(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fetches atomic.Int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		writeJSON(w, http.StatusOK, `{"keys":[{"kid":"k1","kty":"OKP","crv":"Ed25519","x":"`+base64.RawURLEncoding.EncodeToString(public)+`"}]}`)
+	})
+	ctx := context.Background()
+
+	payload := `{"event":"payment.verified"}`
+	signature := base64.StdEncoding.EncodeToString(ed25519.Sign(private, []byte(payload)))
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := client.VerifyWebhookWithPublicKey(ctx, payload, signature, "k1"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := 0; i < 3; i++ {
+		err := client.VerifyWebhookWithPublicKey(ctx, payload, signature, "unknown")
+		if !errors.Is(err, ErrUnknownWebhookKey) {
+			t.Fatalf("unknown key ID: got %v, want ErrUnknownWebhookKey", err)
+		}
+	}
+	if got := fetches.Load(); got != 1 {
+		t.Errorf("JWKS fetched %d times, want 1", got)
+	}
+}
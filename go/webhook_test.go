@@ -0,0 +1,50 @@
+package shegerpay
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWebhookVerifierFutureTimestamps(t *testing.T) {
+	const payload, secret = `{"type":"payment.verified"}`, "whsec_test"
+	verifier := NewWebhookVerifier(5*time.Minute, secret).WithClockSkew(30 * time.Second)
+
+	tests := []struct {
+		name   string
+		offset time.Duration
+		want   error
+	}{
+		{"within skew", 10 * time.Second, nil},
+		{"beyond skew", 2 * time.Minute, ErrTimestampOutsideTolerance},
+		{"within tolerance in the past", -4 * time.Minute, nil},
+		{"beyond tolerance in the past", -10 * time.Minute, ErrTimestampOutsideTolerance},
+	}
+	for _, tt := range tests {
+		header := SignWebhookPayload(payload, secret, time.Now().Add(tt.offset))
+		if err := verifier.Verify(payload, header); !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	// Far enough ahead that the age as a Duration would overflow
+	for _, ts := range []int64{1 << 40, 1<<63 - 1} {
+		header := SignWebhookPayload(payload, secret, time.Unix(ts, 0))
+		if err := verifier.Verify(payload, header); !errors.Is(err, ErrTimestampOutsideTolerance) {
+			t.Errorf("t=%d: got %v, want ErrTimestampOutsideTolerance", ts, err)
+		}
+	}
+}
+
+func TestWebhookVerifierSkewDefaultsToTolerance(t *testing.T) {
+	const payload, secret = `{"type":"payment.verified"}`, "whsec_test"
+	verifier := NewWebhookVerifier(5*time.Minute, secret)
+
+	if err := verifier.Verify(payload, SignWebhookPayload(payload, secret, time.Now().Add(4*time.Minute))); err != nil {
+		t.Errorf("timestamp 4m ahead: %v", err)
+	}
+	err := verifier.Verify(payload, SignWebhookPayload(payload, secret, time.Now().Add(10*time.Minute)))
+	if !errors.Is(err, ErrTimestampOutsideTolerance) {
+		t.Errorf("timestamp 10m ahead: got %v, want ErrTimestampOutsideTolerance", err)
+	}
+}